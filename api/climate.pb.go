@@ -9,6 +9,7 @@ package gbcsdpd_api_v1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 )
@@ -25,11 +26,30 @@ type Measurement struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	SensorMac      string  `protobuf:"bytes,1,opt,name=sensor_mac,json=sensorMac,proto3" json:"sensor_mac,omitempty"`
-	Temperature    float32 `protobuf:"fixed32,10,opt,name=temperature,proto3" json:"temperature,omitempty"`
-	Humidity       float32 `protobuf:"fixed32,11,opt,name=humidity,proto3" json:"humidity,omitempty"`
-	Pressure       float32 `protobuf:"fixed32,12,opt,name=pressure,proto3" json:"pressure,omitempty"`
-	BatteryVoltage float32 `protobuf:"fixed32,20,opt,name=battery_voltage,json=batteryVoltage,proto3" json:"battery_voltage,omitempty"`
+	SensorMac                 string                 `protobuf:"bytes,1,opt,name=sensor_mac,json=sensorMac,proto3" json:"sensor_mac,omitempty"`
+	Timestamp                 *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Temperature               float32                `protobuf:"fixed32,10,opt,name=temperature,proto3" json:"temperature,omitempty"`
+	Humidity                  float32                `protobuf:"fixed32,11,opt,name=humidity,proto3" json:"humidity,omitempty"`
+	Pressure                  float32                `protobuf:"fixed32,12,opt,name=pressure,proto3" json:"pressure,omitempty"`
+	Illuminance               float32                `protobuf:"fixed32,13,opt,name=illuminance,proto3" json:"illuminance,omitempty"`
+	BatteryVoltage            float32                `protobuf:"fixed32,20,opt,name=battery_voltage,json=batteryVoltage,proto3" json:"battery_voltage,omitempty"`
+	BatteryLevel              float32                `protobuf:"fixed32,21,opt,name=battery_level,json=batteryLevel,proto3" json:"battery_level,omitempty"`
+	TxPower                   float32                `protobuf:"fixed32,22,opt,name=tx_power,json=txPower,proto3" json:"tx_power,omitempty"`
+	MeasurementSequenceNumber *uint32                `protobuf:"varint,23,opt,name=measurement_sequence_number,json=measurementSequenceNumber,proto3,oneof" json:"measurement_sequence_number,omitempty"`
+	Co2                       float32                `protobuf:"fixed32,30,opt,name=co2,proto3" json:"co2,omitempty"`
+	Pm1                       float32                `protobuf:"fixed32,31,opt,name=pm1,proto3" json:"pm1,omitempty"`
+	Pm2_5                     float32                `protobuf:"fixed32,32,opt,name=pm2_5,json=pm25,proto3" json:"pm2_5,omitempty"`
+	Pm4                       float32                `protobuf:"fixed32,33,opt,name=pm4,proto3" json:"pm4,omitempty"`
+	Pm10                      float32                `protobuf:"fixed32,34,opt,name=pm10,proto3" json:"pm10,omitempty"`
+	VocIndex                  float32                `protobuf:"fixed32,35,opt,name=voc_index,json=vocIndex,proto3" json:"voc_index,omitempty"`
+	NoxIndex                  float32                `protobuf:"fixed32,36,opt,name=nox_index,json=noxIndex,proto3" json:"nox_index,omitempty"`
+	Motion                    *bool                  `protobuf:"varint,40,opt,name=motion,proto3,oneof" json:"motion,omitempty"`
+	AccelerationX             float32                `protobuf:"fixed32,41,opt,name=acceleration_x,json=accelerationX,proto3" json:"acceleration_x,omitempty"`
+	AccelerationY             float32                `protobuf:"fixed32,42,opt,name=acceleration_y,json=accelerationY,proto3" json:"acceleration_y,omitempty"`
+	AccelerationZ             float32                `protobuf:"fixed32,43,opt,name=acceleration_z,json=accelerationZ,proto3" json:"acceleration_z,omitempty"`
+	MovementCounter           *uint32                `protobuf:"varint,44,opt,name=movement_counter,json=movementCounter,proto3,oneof" json:"movement_counter,omitempty"`
+	Rssi                      *float32               `protobuf:"fixed32,50,opt,name=rssi,proto3,oneof" json:"rssi,omitempty"`
+	Adapter                   string                 `protobuf:"bytes,51,opt,name=adapter,proto3" json:"adapter,omitempty"`
 }
 
 func (x *Measurement) Reset() {
@@ -71,6 +91,13 @@ func (x *Measurement) GetSensorMac() string {
 	return ""
 }
 
+func (x *Measurement) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
 func (x *Measurement) GetTemperature() float32 {
 	if x != nil {
 		return x.Temperature
@@ -92,6 +119,13 @@ func (x *Measurement) GetPressure() float32 {
 	return 0
 }
 
+func (x *Measurement) GetIlluminance() float32 {
+	if x != nil {
+		return x.Illuminance
+	}
+	return 0
+}
+
 func (x *Measurement) GetBatteryVoltage() float32 {
 	if x != nil {
 		return x.BatteryVoltage
@@ -99,6 +133,125 @@ func (x *Measurement) GetBatteryVoltage() float32 {
 	return 0
 }
 
+func (x *Measurement) GetBatteryLevel() float32 {
+	if x != nil {
+		return x.BatteryLevel
+	}
+	return 0
+}
+
+func (x *Measurement) GetTxPower() float32 {
+	if x != nil {
+		return x.TxPower
+	}
+	return 0
+}
+
+func (x *Measurement) GetMeasurementSequenceNumber() uint32 {
+	if x != nil && x.MeasurementSequenceNumber != nil {
+		return *x.MeasurementSequenceNumber
+	}
+	return 0
+}
+
+func (x *Measurement) GetCo2() float32 {
+	if x != nil {
+		return x.Co2
+	}
+	return 0
+}
+
+func (x *Measurement) GetPm1() float32 {
+	if x != nil {
+		return x.Pm1
+	}
+	return 0
+}
+
+func (x *Measurement) GetPm2_5() float32 {
+	if x != nil {
+		return x.Pm2_5
+	}
+	return 0
+}
+
+func (x *Measurement) GetPm4() float32 {
+	if x != nil {
+		return x.Pm4
+	}
+	return 0
+}
+
+func (x *Measurement) GetPm10() float32 {
+	if x != nil {
+		return x.Pm10
+	}
+	return 0
+}
+
+func (x *Measurement) GetVocIndex() float32 {
+	if x != nil {
+		return x.VocIndex
+	}
+	return 0
+}
+
+func (x *Measurement) GetNoxIndex() float32 {
+	if x != nil {
+		return x.NoxIndex
+	}
+	return 0
+}
+
+func (x *Measurement) GetMotion() bool {
+	if x != nil && x.Motion != nil {
+		return *x.Motion
+	}
+	return false
+}
+
+func (x *Measurement) GetAccelerationX() float32 {
+	if x != nil {
+		return x.AccelerationX
+	}
+	return 0
+}
+
+func (x *Measurement) GetAccelerationY() float32 {
+	if x != nil {
+		return x.AccelerationY
+	}
+	return 0
+}
+
+func (x *Measurement) GetAccelerationZ() float32 {
+	if x != nil {
+		return x.AccelerationZ
+	}
+	return 0
+}
+
+func (x *Measurement) GetMovementCounter() uint32 {
+	if x != nil && x.MovementCounter != nil {
+		return *x.MovementCounter
+	}
+	return 0
+}
+
+func (x *Measurement) GetRssi() float32 {
+	if x != nil && x.Rssi != nil {
+		return *x.Rssi
+	}
+	return 0
+}
+
+func (x *Measurement) GetAdapter() string {
+	if x != nil {
+		return x.Adapter
+	}
+	return ""
+}
+
 type MeasurementsPublication struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -151,27 +304,73 @@ var File_api_climate_proto protoreflect.FileDescriptor
 var file_api_climate_proto_rawDesc = []byte{
 	0x0a, 0x11, 0x61, 0x70, 0x69, 0x2f, 0x63, 0x6c, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x2e, 0x70, 0x72,
 	0x6f, 0x74, 0x6f, 0x12, 0x0e, 0x67, 0x62, 0x63, 0x73, 0x64, 0x70, 0x64, 0x2e, 0x61, 0x70, 0x69,
-	0x2e, 0x76, 0x31, 0x22, 0xaf, 0x01, 0x0a, 0x0b, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d,
-	0x65, 0x6e, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x5f, 0x6d, 0x61,
-	0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x4d,
-	0x61, 0x63, 0x12, 0x20, 0x0a, 0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72,
-	0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61,
-	0x74, 0x75, 0x72, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x68, 0x75, 0x6d, 0x69, 0x64, 0x69, 0x74, 0x79,
-	0x18, 0x0b, 0x20, 0x01, 0x28, 0x02, 0x52, 0x08, 0x68, 0x75, 0x6d, 0x69, 0x64, 0x69, 0x74, 0x79,
-	0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x18, 0x0c, 0x20, 0x01,
-	0x28, 0x02, 0x52, 0x08, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x12, 0x27, 0x0a, 0x0f,
-	0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x5f, 0x76, 0x6f, 0x6c, 0x74, 0x61, 0x67, 0x65, 0x18,
-	0x14, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0e, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x56, 0x6f,
-	0x6c, 0x74, 0x61, 0x67, 0x65, 0x22, 0x5a, 0x0a, 0x17, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65,
-	0x6d, 0x65, 0x6e, 0x74, 0x73, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
-	0x12, 0x3f, 0x0a, 0x0c, 0x6d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73,
-	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x67, 0x62, 0x63, 0x73, 0x64, 0x70, 0x64,
-	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d,
-	0x65, 0x6e, 0x74, 0x52, 0x0c, 0x6d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74,
-	0x73, 0x42, 0x2e, 0x5a, 0x2c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
-	0x70, 0x32, 0x30, 0x30, 0x34, 0x61, 0x2f, 0x67, 0x62, 0x63, 0x73, 0x64, 0x70, 0x64, 0x2f, 0x61,
-	0x70, 0x69, 0x3b, 0x67, 0x62, 0x63, 0x73, 0x64, 0x70, 0x64, 0x5f, 0x61, 0x70, 0x69, 0x5f, 0x76,
-	0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0xe7, 0x06, 0x0a, 0x0b, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65,
+	0x6d, 0x65, 0x6e, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x5f, 0x6d,
+	0x61, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72,
+	0x4d, 0x61, 0x63, 0x12, 0x38, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x20, 0x0a,
+	0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x0a, 0x20, 0x01,
+	0x28, 0x02, 0x52, 0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x68, 0x75, 0x6d, 0x69, 0x64, 0x69, 0x74, 0x79, 0x18, 0x0b, 0x20, 0x01, 0x28,
+	0x02, 0x52, 0x08, 0x68, 0x75, 0x6d, 0x69, 0x64, 0x69, 0x74, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x02, 0x52, 0x08, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x69, 0x6c, 0x6c, 0x75, 0x6d,
+	0x69, 0x6e, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0b, 0x69, 0x6c,
+	0x6c, 0x75, 0x6d, 0x69, 0x6e, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x62, 0x61, 0x74,
+	0x74, 0x65, 0x72, 0x79, 0x5f, 0x76, 0x6f, 0x6c, 0x74, 0x61, 0x67, 0x65, 0x18, 0x14, 0x20, 0x01,
+	0x28, 0x02, 0x52, 0x0e, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x56, 0x6f, 0x6c, 0x74, 0x61,
+	0x67, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x5f, 0x6c, 0x65,
+	0x76, 0x65, 0x6c, 0x18, 0x15, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0c, 0x62, 0x61, 0x74, 0x74, 0x65,
+	0x72, 0x79, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x78, 0x5f, 0x70, 0x6f,
+	0x77, 0x65, 0x72, 0x18, 0x16, 0x20, 0x01, 0x28, 0x02, 0x52, 0x07, 0x74, 0x78, 0x50, 0x6f, 0x77,
+	0x65, 0x72, 0x12, 0x43, 0x0a, 0x1b, 0x6d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x5f, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x18, 0x17, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00, 0x52, 0x19, 0x6d, 0x65, 0x61, 0x73, 0x75,
+	0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x4e, 0x75,
+	0x6d, 0x62, 0x65, 0x72, 0x88, 0x01, 0x01, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x6f, 0x32, 0x18, 0x1e,
+	0x20, 0x01, 0x28, 0x02, 0x52, 0x03, 0x63, 0x6f, 0x32, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x6d, 0x31,
+	0x18, 0x1f, 0x20, 0x01, 0x28, 0x02, 0x52, 0x03, 0x70, 0x6d, 0x31, 0x12, 0x13, 0x0a, 0x05, 0x70,
+	0x6d, 0x32, 0x5f, 0x35, 0x18, 0x20, 0x20, 0x01, 0x28, 0x02, 0x52, 0x04, 0x70, 0x6d, 0x32, 0x35,
+	0x12, 0x10, 0x0a, 0x03, 0x70, 0x6d, 0x34, 0x18, 0x21, 0x20, 0x01, 0x28, 0x02, 0x52, 0x03, 0x70,
+	0x6d, 0x34, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6d, 0x31, 0x30, 0x18, 0x22, 0x20, 0x01, 0x28, 0x02,
+	0x52, 0x04, 0x70, 0x6d, 0x31, 0x30, 0x12, 0x1b, 0x0a, 0x09, 0x76, 0x6f, 0x63, 0x5f, 0x69, 0x6e,
+	0x64, 0x65, 0x78, 0x18, 0x23, 0x20, 0x01, 0x28, 0x02, 0x52, 0x08, 0x76, 0x6f, 0x63, 0x49, 0x6e,
+	0x64, 0x65, 0x78, 0x12, 0x1b, 0x0a, 0x09, 0x6e, 0x6f, 0x78, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78,
+	0x18, 0x24, 0x20, 0x01, 0x28, 0x02, 0x52, 0x08, 0x6e, 0x6f, 0x78, 0x49, 0x6e, 0x64, 0x65, 0x78,
+	0x12, 0x1b, 0x0a, 0x06, 0x6d, 0x6f, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x28, 0x20, 0x01, 0x28, 0x08,
+	0x48, 0x01, 0x52, 0x06, 0x6d, 0x6f, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x25, 0x0a,
+	0x0e, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x78, 0x18,
+	0x29, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0d, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x58, 0x12, 0x25, 0x0a, 0x0e, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x79, 0x18, 0x2a, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0d, 0x61, 0x63,
+	0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x59, 0x12, 0x25, 0x0a, 0x0e, 0x61,
+	0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x7a, 0x18, 0x2b, 0x20,
+	0x01, 0x28, 0x02, 0x52, 0x0d, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x5a, 0x12, 0x2e, 0x0a, 0x10, 0x6d, 0x6f, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x18, 0x2c, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x02, 0x52, 0x0f,
+	0x6d, 0x6f, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x88,
+	0x01, 0x01, 0x12, 0x17, 0x0a, 0x04, 0x72, 0x73, 0x73, 0x69, 0x18, 0x32, 0x20, 0x01, 0x28, 0x02,
+	0x48, 0x03, 0x52, 0x04, 0x72, 0x73, 0x73, 0x69, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x07, 0x61,
+	0x64, 0x61, 0x70, 0x74, 0x65, 0x72, 0x18, 0x33, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64,
+	0x61, 0x70, 0x74, 0x65, 0x72, 0x42, 0x1e, 0x0a, 0x1c, 0x5f, 0x6d, 0x65, 0x61, 0x73, 0x75, 0x72,
+	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x6e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x6d, 0x6f, 0x74, 0x69, 0x6f, 0x6e,
+	0x42, 0x13, 0x0a, 0x11, 0x5f, 0x6d, 0x6f, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x65, 0x72, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x72, 0x73, 0x73, 0x69, 0x22, 0x5a,
+	0x0a, 0x17, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x50, 0x75,
+	0x62, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x3f, 0x0a, 0x0c, 0x6d, 0x65, 0x61,
+	0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x1b, 0x2e, 0x67, 0x62, 0x63, 0x73, 0x64, 0x70, 0x64, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31,
+	0x2e, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x0c, 0x6d, 0x65,
+	0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x42, 0x2e, 0x5a, 0x2c, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x32, 0x30, 0x30, 0x34, 0x61, 0x2f,
+	0x67, 0x62, 0x63, 0x73, 0x64, 0x70, 0x64, 0x2f, 0x61, 0x70, 0x69, 0x3b, 0x67, 0x62, 0x63, 0x73,
+	0x64, 0x70, 0x64, 0x5f, 0x61, 0x70, 0x69, 0x5f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -190,14 +389,16 @@ var file_api_climate_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_api_climate_proto_goTypes = []interface{}{
 	(*Measurement)(nil),             // 0: gbcsdpd.api.v1.Measurement
 	(*MeasurementsPublication)(nil), // 1: gbcsdpd.api.v1.MeasurementsPublication
+	(*timestamppb.Timestamp)(nil),   // 2: google.protobuf.Timestamp
 }
 var file_api_climate_proto_depIdxs = []int32{
-	0, // 0: gbcsdpd.api.v1.MeasurementsPublication.measurements:type_name -> gbcsdpd.api.v1.Measurement
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	2, // 0: gbcsdpd.api.v1.Measurement.timestamp:type_name -> google.protobuf.Timestamp
+	0, // 1: gbcsdpd.api.v1.MeasurementsPublication.measurements:type_name -> gbcsdpd.api.v1.Measurement
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_api_climate_proto_init() }
@@ -231,6 +432,7 @@ func file_api_climate_proto_init() {
 			}
 		}
 	}
+	file_api_climate_proto_msgTypes[0].OneofWrappers = []interface{}{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
@@ -15,26 +15,133 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
-	"math"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	api "github.com/p2004a/gbcsdpd/api"
 	"github.com/p2004a/gbcsdpd/pkg/blelistener"
 	"github.com/p2004a/gbcsdpd/pkg/config"
-	"github.com/p2004a/gbcsdpd/pkg/ruuviparse"
+	"github.com/p2004a/gbcsdpd/pkg/decoders"
 	sinkspkg "github.com/p2004a/gbcsdpd/pkg/sinks"
 )
 
-const (
-	ruuviManufacturerID = 0x0499
-)
+// newListener starts listening for advertisements on all configured adapters
+// with the configured backend.
+func newListener(ctx context.Context, conf *config.Config, registry *decoders.Registry) (blelistener.Listener, error) {
+	var listeners []blelistener.Listener
+	for _, adapter := range conf.Adapters {
+		listener, err := newAdapterListener(ctx, conf, registry, adapter)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("adapter %s: %v", adapter, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) == 1 {
+		return listeners[0], nil
+	}
+	return blelistener.Merge(listeners...), nil
+}
 
-func nilToNaN(value *float32) float32 {
-	if value == nil {
-		return float32(math.NaN())
+// newAdapterListener starts listening for advertisements on a single adapter.
+func newAdapterListener(ctx context.Context, conf *config.Config, registry *decoders.Registry, adapter string) (blelistener.Listener, error) {
+	if conf.Backend == config.HCI {
+		return blelistener.NewHCIListener(ctx, adapter)
+	}
+	options := &blelistener.Options{
+		PowerOn:            conf.PowerOnAdapter,
+		CacheSize:          conf.DeviceCache.MaxSize,
+		CacheTTL:           conf.DeviceCache.TTL,
+		RemoveStaleDevices: conf.DeviceCache.RemoveStaleDevices,
+		IsSensor: func(adv *blelistener.Advertisement) bool {
+			return registry.Match(adv) != nil
+		},
+	}
+	switch conf.ScanMode {
+	case config.DISCOVERY:
+		options.ScanMode = blelistener.ScanModeDiscovery
+	case config.MONITOR:
+		options.ScanMode = blelistener.ScanModeMonitor
+	default:
+		options.ScanMode = blelistener.ScanModeAuto
+	}
+	patterns, err := registry.MonitorPatterns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create advertisement monitor patterns: %v", err)
+	}
+	options.MonitorPatterns = patterns
+	if f := conf.DiscoveryFilter; f != nil {
+		options.DiscoveryFilter = &blelistener.DiscoveryFilter{
+			Transport:     f.Transport,
+			DuplicateData: f.DuplicateData,
+			RSSI:          f.RSSI,
+			UUIDs:         f.UUIDs,
+		}
+	}
+	return blelistener.NewAdvListener(ctx, adapter, options)
+}
+
+// run decodes advertisements received from the listener and publishes
+// measurements to sinks until the listener stops. It returns the listener
+// error.
+func run(listener blelistener.Listener, registry *decoders.Registry, sensorAllowlist []net.HardwareAddr, sinks []sinkspkg.Sink) error {
+	allowlist := make(map[string]bool)
+	for _, addr := range sensorAllowlist {
+		allowlist[addr.String()] = true
+	}
+
+	deduplicator := decoders.NewDeduplicator()
+
+	for adv := range listener.Advertisements() {
+		decoder := registry.Match(&adv)
+		if decoder == nil {
+			continue
+		}
+
+		if len(allowlist) > 0 && !allowlist[adv.Address.String()] {
+			continue
+		}
+
+		measurement, err := decoder.Decode(&adv)
+		if err != nil {
+			log.Printf("Failed to decode %s advertisement from %s: %v", decoder.Name(), adv.Address, err)
+			continue
+		}
+		if deduplicator.IsDuplicate(&adv, measurement) {
+			continue
+		}
+		for _, sink := range sinks {
+			sink.Publish(measurement)
+		}
+	}
+	return listener.Err()
+}
+
+// logDropped periodically logs advertisements dropped by the listener because
+// sinks couldn't keep up, until ctx is cancelled.
+func logDropped(ctx context.Context, listener blelistener.Listener) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	var lastDropped uint64
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		if dropped := listener.Dropped(); dropped != lastDropped {
+			log.Printf("Dropped %d advertisements in the last minute, sinks are too slow", dropped-lastDropped)
+			lastDropped = dropped
+		}
 	}
-	return *value
 }
 
 func main() {
@@ -62,42 +169,21 @@ func main() {
 		sinks = append(sinks, sink)
 	}
 
-	advListener, err := blelistener.NewAdvListener(conf.Adapter)
-	if err != nil {
-		log.Fatalf("Failed to listen for BLE advertisements: %v", err)
-	}
-
-	sensorsAllowlist := make(map[string]bool)
-	for _, addr := range conf.SensorAllowlist {
-		sensorsAllowlist[addr.String()] = true
-	}
+	registry := decoders.NewRegistry(
+		decoders.NewRuuviDecoder(&conf.Decoders.Ruuvi),
+		decoders.NewATCDecoder(),
+		decoders.NewBTHomeDecoder(&conf.Decoders.BTHome),
+	)
 
-	for adv := range advListener.Advertisements() {
-		data, ok := adv.ManufacturerData[ruuviManufacturerID]
-		if !ok {
-			continue
-		}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		if len(sensorsAllowlist) > 0 && !sensorsAllowlist[adv.Address.String()] {
-			continue
-		}
-
-		ruuviData, err := ruuviparse.Parse(data)
-		if err != nil {
-			log.Printf("Failed to parse ruuvi data: %v", err)
-		}
-		measuement := &api.Measurement{
-			SensorMac:      adv.Address.String(),
-			Temperature:    nilToNaN(ruuviData.Temperature),
-			Humidity:       nilToNaN(ruuviData.Humidity),
-			Pressure:       nilToNaN(ruuviData.Pressure),
-			BatteryVoltage: nilToNaN(ruuviData.BatteryVoltage),
-		}
-		for _, sink := range sinks {
-			sink.Publish(measuement)
-		}
+	listener, err := newListener(ctx, conf, registry)
+	if err != nil {
+		log.Fatalf("Failed to listen for BLE advertisements: %v", err)
 	}
-	if advListener.Err != nil {
-		log.Fatalf("BLE Advertisement listener failed: %v", advListener.Err)
+	go logDropped(ctx, listener)
+	if err := run(listener, registry, conf.SensorAllowlist, sinks); err != nil {
+		log.Fatalf("BLE Advertisement listener failed: %v", err)
 	}
 }
@@ -0,0 +1,87 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/blelistener"
+	"github.com/p2004a/gbcsdpd/pkg/config"
+	"github.com/p2004a/gbcsdpd/pkg/decoders"
+	sinkspkg "github.com/p2004a/gbcsdpd/pkg/sinks"
+)
+
+type fakeListener struct {
+	advs chan blelistener.Advertisement
+	err  error
+}
+
+func (l *fakeListener) Advertisements() <-chan blelistener.Advertisement { return l.advs }
+func (l *fakeListener) Err() error                                       { return l.err }
+func (l *fakeListener) Dropped() uint64                                  { return 0 }
+func (l *fakeListener) Close() error                                     { return nil }
+
+type fakeSink struct {
+	m            sync.Mutex
+	measurements []*api.Measurement
+}
+
+func (s *fakeSink) Publish(m *api.Measurement) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.measurements = append(s.measurements, m)
+}
+
+func ruuviAdv(mac net.HardwareAddr, adapter string, data []byte) blelistener.Advertisement {
+	return blelistener.Advertisement{
+		Address:          mac,
+		Adapter:          adapter,
+		ManufacturerData: blelistener.ManufacturerData{0x0499: data},
+	}
+}
+
+func TestRun(t *testing.T) {
+	allowed := net.HardwareAddr{0xcb, 0xb8, 0x33, 0x4c, 0x88, 0x4f}
+	other := net.HardwareAddr{0xcb, 0xb8, 0x33, 0x4c, 0x88, 0x50}
+	data := []byte{
+		0x05, 0x12, 0xfc, 0x53, 0x94, 0xc3, 0x7c, 0x00, 0x04, 0xff, 0xfc, 0x04,
+		0x0c, 0xac, 0x36, 0x42, 0x00, 0xcd, 0xcb, 0xb8, 0x33, 0x4c, 0x88, 0x4f}
+
+	listenerErr := errors.New("adapter disappeared")
+	listener := &fakeListener{advs: make(chan blelistener.Advertisement, 10), err: listenerErr}
+	listener.advs <- ruuviAdv(allowed, "hci0", data)
+	listener.advs <- ruuviAdv(allowed, "hci0", data) // duplicate
+	listener.advs <- ruuviAdv(allowed, "hci1", data) // duplicate from other adapter
+	listener.advs <- ruuviAdv(other, "hci0", data)   // not on allowlist
+	listener.advs <- blelistener.Advertisement{
+		Address:          allowed,
+		ManufacturerData: blelistener.ManufacturerData{0x1234: {0x01}},
+	} // no decoder
+	close(listener.advs)
+
+	sink := &fakeSink{}
+	registry := decoders.NewRegistry(decoders.NewRuuviDecoder(&config.RuuviDecoder{}))
+	err := run(listener, registry, []net.HardwareAddr{allowed}, []sinkspkg.Sink{sink})
+	if err != listenerErr {
+		t.Errorf("run returned %v, expected %v", err, listenerErr)
+	}
+	if len(sink.measurements) != 1 || sink.measurements[0].SensorMac != allowed.String() || sink.measurements[0].Adapter != "hci0" {
+		t.Errorf("unexpected measurements published: %v", sink.measurements)
+	}
+}
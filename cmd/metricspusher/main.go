@@ -68,8 +68,8 @@ func parsePubsubMessage(data []byte) (*measurementPubSubMessage, error) {
 	if subFolder != "v1" {
 		return nil, fmt.Errorf("Only the v1 version of measurements is supported, got: %s", subFolder)
 	}
-	measuementPub := &gbcsdpdapipb.MeasurementsPublication{}
-	if err := proto.Unmarshal(msg.Message.Data, measuementPub); err != nil {
+	measurementPub := &gbcsdpdapipb.MeasurementsPublication{}
+	if err := proto.Unmarshal(msg.Message.Data, measurementPub); err != nil {
 		return nil, fmt.Errorf("Failed to unmarshal MeasurementsPublication from pubsub data: %v", err)
 	}
 	return &measurementPubSubMessage{
@@ -77,7 +77,7 @@ func parsePubsubMessage(data []byte) (*measurementPubSubMessage, error) {
 		DeviceRegistryLocaton: deviceRegistryLocation,
 		ProjectID:             projectID,
 		PublishTime:           msg.Message.PublishTime,
-		Measurements:          measuementPub.Measurements,
+		Measurements:          measurementPub.Measurements,
 	}, nil
 }
 
@@ -109,6 +109,44 @@ func appendMeasurementTimeSeries(
 	})
 }
 
+// measurementTime returns the time when the measurement was taken. Older
+// publishers don't set the timestamp, the Pub/Sub publish time is the best
+// approximation then.
+func measurementTime(psmsg *measurementPubSubMessage, m *gbcsdpdapipb.Measurement) time.Time {
+	if m.Timestamp != nil {
+		return m.Timestamp.AsTime()
+	}
+	return psmsg.PublishTime
+}
+
+// measurementsTimeSeries converts all measurements from the message into
+// time series data points.
+func measurementsTimeSeries(psmsg *measurementPubSubMessage) []*monitoringpb.TimeSeries {
+	var ts []*monitoringpb.TimeSeries
+	for _, m := range psmsg.Measurements {
+		// https://cloud.google.com/monitoring/api/resources#tag_generic_node
+		res := &monitoredrespb.MonitoredResource{
+			Type: "generic_node",
+			Labels: map[string]string{
+				"project_id": psmsg.ProjectID,
+				"location":   psmsg.DeviceRegistryLocaton,
+				"namespace":  psmsg.DeviceID,
+				"node_id":    m.SensorMac,
+			},
+		}
+		t := measurementTime(psmsg, m)
+		ts = appendMeasurementTimeSeries(ts, res, "temperature", t, m.Temperature)
+		ts = appendMeasurementTimeSeries(ts, res, "humidity", t, m.Humidity)
+		ts = appendMeasurementTimeSeries(ts, res, "pressure", t, m.Pressure)
+		ts = appendMeasurementTimeSeries(ts, res, "battery", t, m.BatteryVoltage)
+		// Older publishers don't report rssi at all.
+		if m.Rssi != nil {
+			ts = appendMeasurementTimeSeries(ts, res, "rssi", t, *m.Rssi)
+		}
+	}
+	return ts
+}
+
 func main() {
 	http.HandleFunc("/", handlePubSub)
 	port := os.Getenv("PORT")
@@ -149,23 +187,7 @@ func handlePubSub(w http.ResponseWriter, r *http.Request) {
 	}
 	defer client.Close()
 
-	var ts []*monitoringpb.TimeSeries
-	for _, m := range psmsg.Measurements {
-		// https://cloud.google.com/monitoring/api/resources#tag_generic_node
-		res := &monitoredrespb.MonitoredResource{
-			Type: "generic_node",
-			Labels: map[string]string{
-				"project_id": psmsg.ProjectID,
-				"location":   psmsg.DeviceRegistryLocaton,
-				"namespace":  psmsg.DeviceID,
-				"node_id":    m.SensorMac,
-			},
-		}
-		ts = appendMeasurementTimeSeries(ts, res, "temperature", psmsg.PublishTime, m.Temperature)
-		ts = appendMeasurementTimeSeries(ts, res, "humidity", psmsg.PublishTime, m.Humidity)
-		ts = appendMeasurementTimeSeries(ts, res, "pressure", psmsg.PublishTime, m.Pressure)
-		ts = appendMeasurementTimeSeries(ts, res, "battery", psmsg.PublishTime, m.BatteryVoltage)
-	}
+	ts := measurementsTimeSeries(psmsg)
 
 	// Writes time series data.
 	if err := client.CreateTimeSeries(r.Context(), &monitoringpb.CreateTimeSeriesRequest{
@@ -17,6 +17,8 @@ package main
 import (
 	"encoding/base64"
 	"encoding/json"
+	"math"
+	"path"
 	"testing"
 	"time"
 
@@ -24,6 +26,7 @@ import (
 	api "github.com/p2004a/gbcsdpd/api"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestParsePubsubMessage(t *testing.T) {
@@ -38,6 +41,7 @@ func TestParsePubsubMessage(t *testing.T) {
 			},
 			{
 				SensorMac:      "01:23:45:67:89:02",
+				Timestamp:      timestamppb.New(time.Date(2020, time.October, 22, 15, 5, 12, 0, time.UTC)),
 				Temperature:    22.0,
 				Humidity:       51.0,
 				Pressure:       1024.0,
@@ -84,4 +88,76 @@ func TestParsePubsubMessage(t *testing.T) {
 	if diff := cmp.Diff(msg, expectedMsg, protocmp.Transform()); diff != "" {
 		t.Errorf("unexpected difference:\n%v", diff)
 	}
+
+	if mt := measurementTime(msg, msg.Measurements[0]); !mt.Equal(expectedMsg.PublishTime) {
+		t.Errorf("measurement without timestamp has wrong time: got %v, expected %v", mt, expectedMsg.PublishTime)
+	}
+	if mt, expected := measurementTime(msg, msg.Measurements[1]), time.Date(2020, time.October, 22, 15, 5, 12, 0, time.UTC); !mt.Equal(expected) {
+		t.Errorf("measurement with timestamp has wrong time: got %v, expected %v", mt, expected)
+	}
+}
+
+func TestMeasurementsTimeSeries(t *testing.T) {
+	msg := &measurementPubSubMessage{
+		DeviceID:              "testing-device",
+		DeviceRegistryLocaton: "europe-west1",
+		ProjectID:             "some-project-123123",
+		PublishTime:           time.Date(2020, time.October, 22, 15, 7, 36, 0, time.UTC),
+		Measurements: []*api.Measurement{
+			{
+				SensorMac:      "01:23:45:67:89:01",
+				Temperature:    20.0,
+				Humidity:       50.0,
+				Pressure:       1024.0,
+				BatteryVoltage: 3.0,
+			},
+			{
+				SensorMac:      "01:23:45:67:89:02",
+				Timestamp:      timestamppb.New(time.Date(2020, time.October, 22, 15, 5, 12, 0, time.UTC)),
+				Temperature:    22.0,
+				Humidity:       51.0,
+				Pressure:       1024.0,
+				BatteryVoltage: float32(math.NaN()),
+				Rssi:           proto.Float32(-70.0),
+			},
+			{
+				// Has timestamp, but doesn't report rssi.
+				SensorMac:      "01:23:45:67:89:03",
+				Timestamp:      timestamppb.New(time.Date(2020, time.October, 22, 15, 5, 13, 0, time.UTC)),
+				Temperature:    23.0,
+				Humidity:       float32(math.NaN()),
+				Pressure:       float32(math.NaN()),
+				BatteryVoltage: float32(math.NaN()),
+			},
+			{
+				// Reports rssi without timestamp.
+				SensorMac:      "01:23:45:67:89:04",
+				Temperature:    24.0,
+				Humidity:       float32(math.NaN()),
+				Pressure:       float32(math.NaN()),
+				BatteryVoltage: float32(math.NaN()),
+				Rssi:           proto.Float32(-80.0),
+			},
+		},
+	}
+	var got []string
+	for _, ts := range measurementsTimeSeries(msg) {
+		got = append(got, ts.Resource.Labels["node_id"]+" "+path.Base(ts.Metric.Type))
+	}
+	expected := []string{
+		"01:23:45:67:89:01 temperature",
+		"01:23:45:67:89:01 humidity",
+		"01:23:45:67:89:01 pressure",
+		"01:23:45:67:89:01 battery",
+		"01:23:45:67:89:02 temperature",
+		"01:23:45:67:89:02 humidity",
+		"01:23:45:67:89:02 pressure",
+		"01:23:45:67:89:02 rssi",
+		"01:23:45:67:89:03 temperature",
+		"01:23:45:67:89:04 temperature",
+		"01:23:45:67:89:04 rssi",
+	}
+	if diff := cmp.Diff(got, expected); diff != "" {
+		t.Errorf("unexpected time series:\n%v", diff)
+	}
 }
@@ -90,6 +90,7 @@ locals {
     { name = "humidity", unit = "%%{RH}", pretty = "Humidity" },
     { name = "pressure", unit = "{hPa}", pretty = "Pressure" },
     { name = "battery", unit = "{V}", pretty = "Battery voltage" },
+    { name = "rssi", unit = "{dBm}", pretty = "Signal strength" },
   ]
 }
 
@@ -0,0 +1,130 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package atcparse parses the Environmental Sensing (0x181A) service data
+// broadcasted by Xiaomi thermometers (eg LYWSD03MMC) flashed with the ATC1441
+// or pvvx custom firmware.
+package atcparse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// ATCDataFormat represents version of the custom firmware advertising format.
+type ATCDataFormat uint8
+
+// List of allowed ATCDataFormat values.
+const (
+	UNSPECIFIED ATCDataFormat = iota
+	ATC1441
+	PVVX
+)
+
+// ATCData contains a parsed data from the custom firmware advertisement.
+type ATCData struct {
+	DataFormat         ATCDataFormat
+	Temperature        *float32         // C
+	Humidity           *float32         // RH %
+	BatteryVoltage     *float32         // V
+	BatteryLevel       *uint            // %
+	MeasurementCounter *uint            // counter
+	Flags              *uint            // pvvx flags bitfield
+	Mac                net.HardwareAddr // MAC
+}
+
+// Parse takes the 0x181A Service Data field from BLE advertisement and parses
+// the content into ATCData.
+func Parse(data []byte) (*ATCData, error) {
+	switch len(data) {
+	case binary.Size(atc1441Data{}):
+		ad, err := parseATC1441(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse data in ATC1441 format: %v", err)
+		}
+		return ad, nil
+	case binary.Size(pvvxData{}):
+		ad, err := parsePVVX(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse data in pvvx format: %v", err)
+		}
+		return ad, nil
+	default:
+		return nil, fmt.Errorf("only unencrypted ATC1441 and pvvx formats supported, got %d bytes of data", len(data))
+	}
+}
+
+func nilF32(v float32) *float32 { return &v }
+func nilUint(v uint) *uint      { return &v }
+
+// https://github.com/atc1441/ATC_MiThermometer#advertising-format-of-the-custom-firmware
+type atc1441Data struct {
+	Mac                [6]byte
+	Temperature        int16
+	Humidity           uint8
+	BatteryLevel       uint8
+	BatteryVoltage     uint16
+	MeasurementCounter uint8
+}
+
+func parseATC1441(data []byte) (*ATCData, error) {
+	var ad atc1441Data
+	if err := binary.Read(bytes.NewBuffer(data), binary.BigEndian, &ad); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal struct: %v", err)
+	}
+	return &ATCData{
+		DataFormat:         ATC1441,
+		Temperature:        nilF32(float32(ad.Temperature) / 10.0),
+		Humidity:           nilF32(float32(ad.Humidity)),
+		BatteryVoltage:     nilF32(float32(ad.BatteryVoltage) / 1000.0),
+		BatteryLevel:       nilUint(uint(ad.BatteryLevel)),
+		MeasurementCounter: nilUint(uint(ad.MeasurementCounter)),
+		Mac:                ad.Mac[:],
+	}, nil
+}
+
+// https://github.com/pvvx/ATC_MiThermometer#custom-format-all-data-little-endian
+type pvvxData struct {
+	Mac                [6]byte
+	Temperature        int16
+	Humidity           uint16
+	BatteryVoltage     uint16
+	BatteryLevel       uint8
+	MeasurementCounter uint8
+	Flags              uint8
+}
+
+func parsePVVX(data []byte) (*ATCData, error) {
+	var pd pvvxData
+	if err := binary.Read(bytes.NewBuffer(data), binary.LittleEndian, &pd); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal struct: %v", err)
+	}
+	// pvvx format transmits MAC in the reversed byte order.
+	mac := make(net.HardwareAddr, len(pd.Mac))
+	for i := range pd.Mac {
+		mac[i] = pd.Mac[len(pd.Mac)-1-i]
+	}
+	return &ATCData{
+		DataFormat:         PVVX,
+		Temperature:        nilF32(float32(pd.Temperature) / 100.0),
+		Humidity:           nilF32(float32(pd.Humidity) / 100.0),
+		BatteryVoltage:     nilF32(float32(pd.BatteryVoltage) / 1000.0),
+		BatteryLevel:       nilUint(uint(pd.BatteryLevel)),
+		MeasurementCounter: nilUint(uint(pd.MeasurementCounter)),
+		Flags:              nilUint(uint(pd.Flags)),
+		Mac:                mac,
+	}, nil
+}
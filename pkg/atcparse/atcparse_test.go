@@ -0,0 +1,156 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atcparse
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"testing"
+)
+
+func toB(s string) []byte {
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		panic("Got invalid hex string")
+	}
+	return decoded
+}
+
+func f32Ptr(v float32) *float32 { return &v }
+func uintPtr(v uint) *uint      { return &v }
+
+func f32PtrToStr(v *float32) string {
+	if v == nil {
+		return "nil"
+	}
+	return fmt.Sprint(*v)
+}
+
+func uintPtrToStr(v *uint) string {
+	if v == nil {
+		return "nil"
+	}
+	return fmt.Sprint(*v)
+}
+
+func assertF32Eq(t *testing.T, param string, value, expected *float32) {
+	if value == nil || expected == nil {
+		if value != expected {
+			t.Errorf("%s not equal: value %s, expected %s", param, f32PtrToStr(value), f32PtrToStr(expected))
+		}
+	} else if math.Abs(float64(*value-*expected)) > 0.0001 {
+		t.Errorf("%s not equal: value %s, expected %s", param, f32PtrToStr(value), f32PtrToStr(expected))
+	}
+}
+
+func assertUintEq(t *testing.T, param string, value, expected *uint) {
+	if value == nil || expected == nil {
+		if value != expected {
+			t.Errorf("%s not equal: value %s, expected %s", param, uintPtrToStr(value), uintPtrToStr(expected))
+		}
+	} else if *value != *expected {
+		t.Errorf("%s not equal: value %s, expected %s", param, uintPtrToStr(value), uintPtrToStr(expected))
+	}
+}
+
+func TestParsingValid(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     []byte
+		expected *ATCData
+	}{
+		{"ATC1441 valid", toB("A4C13801020300EB2D570B8612"), &ATCData{
+			DataFormat:         ATC1441,
+			Temperature:        f32Ptr(23.5),
+			Humidity:           f32Ptr(45),
+			BatteryVoltage:     f32Ptr(2.95),
+			BatteryLevel:       uintPtr(87),
+			MeasurementCounter: uintPtr(18),
+			Mac:                []byte{0xA4, 0xC1, 0x38, 0x01, 0x02, 0x03},
+		}},
+		{"ATC1441 negative temperature", toB("A4C138010203FFCC64640C1CFF"), &ATCData{
+			DataFormat:         ATC1441,
+			Temperature:        f32Ptr(-5.2),
+			Humidity:           f32Ptr(100),
+			BatteryVoltage:     f32Ptr(3.1),
+			BatteryLevel:       uintPtr(100),
+			MeasurementCounter: uintPtr(255),
+			Mac:                []byte{0xA4, 0xC1, 0x38, 0x01, 0x02, 0x03},
+		}},
+		{"pvvx valid", toB("03020138C1A42909D711860B571204"), &ATCData{
+			DataFormat:         PVVX,
+			Temperature:        f32Ptr(23.45),
+			Humidity:           f32Ptr(45.67),
+			BatteryVoltage:     f32Ptr(2.95),
+			BatteryLevel:       uintPtr(87),
+			MeasurementCounter: uintPtr(18),
+			Flags:              uintPtr(4),
+			Mac:                []byte{0xA4, 0xC1, 0x38, 0x01, 0x02, 0x03},
+		}},
+		{"pvvx negative temperature", toB("03020138C1A4FCFD0000B80B000000"), &ATCData{
+			DataFormat:         PVVX,
+			Temperature:        f32Ptr(-5.16),
+			Humidity:           f32Ptr(0),
+			BatteryVoltage:     f32Ptr(3.0),
+			BatteryLevel:       uintPtr(0),
+			MeasurementCounter: uintPtr(0),
+			Flags:              uintPtr(0),
+			Mac:                []byte{0xA4, 0xC1, 0x38, 0x01, 0x02, 0x03},
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Parse(tc.data)
+			if err != nil {
+				t.Fatalf("couldn't parse data: %v", err)
+			}
+			if res.DataFormat != tc.expected.DataFormat {
+				t.Errorf("data format not equal: value %d, expected %d", res.DataFormat, tc.expected.DataFormat)
+			}
+			assertF32Eq(t, "temperature", res.Temperature, tc.expected.Temperature)
+			assertF32Eq(t, "humidity", res.Humidity, tc.expected.Humidity)
+			assertF32Eq(t, "battery voltage", res.BatteryVoltage, tc.expected.BatteryVoltage)
+			assertUintEq(t, "battery level", res.BatteryLevel, tc.expected.BatteryLevel)
+			assertUintEq(t, "measurement counter", res.MeasurementCounter, tc.expected.MeasurementCounter)
+			assertUintEq(t, "flags", res.Flags, tc.expected.Flags)
+			if res.Mac.String() != net.HardwareAddr(tc.expected.Mac).String() {
+				t.Errorf("MAC not equal: value %s, expected %s", res.Mac, tc.expected.Mac)
+			}
+		})
+	}
+}
+
+func TestParsingInvalid(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", []byte{}},
+		{"encrypted pvvx", toB("0102030405060708")},
+		{"too long", toB("A4C13801020300EB2D570B861200")},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.data)
+			if err == nil {
+				t.Fatalf("Expected error, got success")
+			}
+		})
+	}
+}
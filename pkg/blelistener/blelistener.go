@@ -14,23 +14,30 @@
 
 // Package blelistener implements AdvListener which listens for BLE
 // advertisements and published them as Advertisement objects via
-// channel returned by Advertisements()
+// channel returned by Advertisements(). All listeners implement the Listener
+// interface.
 //
 // Currently this package does it by using org.bluez.Adapter1 interface:
-// Starts discovery and listens for changes to ManufacturerData and RSSI
-// properties of all org.bluez.Device1 objects under adapter that are
+// Starts discovery and listens for changes to ManufacturerData, ServiceData
+// and RSSI properties of all org.bluez.Device1 objects under adapter that are
 // propagated via org.freedesktop.DBus.Properties.PropertiesChanged signal.
 // See https://git.kernel.org/pub/scm/bluetooth/bluez.git/tree/doc for Bluez
 // D-Bus API documentation.
 //
-// In the future this package might switch to org.bluez.AdvertisementMonitor1
-// API but it's currently experimental and not available in stable builds.
+// When BlueZ supports it, instead of discovery, AdvListener can register an
+// org.bluez.AdvertisementMonitor1 that makes BlueZ passively scan for
+// advertisements matching given patterns. See ScanMode.
+//
+// Alternatively, HCIListener receives advertisements directly from the raw
+// HCI socket, for systems where running bluetoothd is not desired.
 package blelistener
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -66,14 +73,59 @@ type interfacesAddedSignal struct {
 // see https://git.kernel.org/pub/scm/bluetooth/bluez.git/tree/doc/device-api.txt
 type manufacturerDataProperty map[uint16]dbus.Variant
 
+// org.bluez.Device1.ServiceData property
+// see https://git.kernel.org/pub/scm/bluetooth/bluez.git/tree/doc/device-api.txt
+type serviceDataProperty map[string]dbus.Variant
+
 // ManufacturerData represents a map from manufacturer ID to raw manufacturer
 // data embeded in a BLE advertisement.
 type ManufacturerData map[uint16][]byte
 
+// ServiceData represents a map from service UUID to raw service data embeded
+// in a BLE advertisement. UUIDs are in the lowercase 128-bit string format, eg
+// 0000181a-0000-1000-8000-00805f9b34fb.
+type ServiceData map[string][]byte
+
 // Advertisement represents a BLE advertisement.
 type Advertisement struct {
 	Address          net.HardwareAddr
+	AddressType      string // "public" or "random"
+	Name             string
 	ManufacturerData ManufacturerData
+	ServiceData      ServiceData
+	RSSI             *int16    // dBm, nil if not available
+	TxPower          *int16    // dBm, nil if not advertised
+	Adapter          string    // Name of the adapter that received advertisement
+	ReceivedAt       time.Time // Time when the advertisement was received
+}
+
+// Listener is a source of BLE advertisements.
+type Listener interface {
+	// Advertisements returns a channel that the listener publishes
+	// advertisements on. The channel is closed when the listener stops,
+	// because of an error, Close, or cancellation of the context the listener
+	// was created with.
+	Advertisements() <-chan Advertisement
+
+	// Err returns the error that caused the listener to stop, or nil if it
+	// was stopped by Close or context cancellation. It must be called only
+	// after the Advertisements() channel is closed.
+	Err() error
+
+	// Dropped returns the number of advertisements dropped because they
+	// weren't received from the Advertisements() channel fast enough. Only
+	// the newest advertisement of every device is kept until it's received,
+	// replaced older ones are counted as dropped when their payload differs.
+	Dropped() uint64
+
+	// Close stops the listener. It's safe to call it multiple times.
+	Close() error
+}
+
+// ServiceDataUUID returns the full 128-bit UUID string of the 16-bit
+// Bluetooth SIG assigned service UUID as used for keys of ServiceData.
+func ServiceDataUUID(uuid16 uint16) string {
+	return fmt.Sprintf("0000%04x-0000-1000-8000-00805f9b34fb", uuid16)
 }
 
 func parseDeviceMAC(v dbus.Variant) (net.HardwareAddr, error) {
@@ -105,8 +157,82 @@ func parseManufacturerData(v dbus.Variant) (ManufacturerData, error) {
 	return res, nil
 }
 
+func parseServiceData(v dbus.Variant) (ServiceData, error) {
+	var sd serviceDataProperty
+	if err := v.Store(&sd); err != nil {
+		return nil, fmt.Errorf("given data is not a org.bluez.Device1.ServiceData: %v", err)
+	}
+	res := make(ServiceData)
+	for k, v := range sd {
+		var data []byte
+		if err := v.Store(&data); err != nil {
+			return nil, fmt.Errorf("failed to store bytes: %v", err)
+		}
+		res[strings.ToLower(k)] = data
+	}
+	return res, nil
+}
+
+// updateAdvertisementFromProperties updates adv with values of the
+// org.bluez.Device1 properties present in props. It returns true if any of the
+// properties that are updated on every received advertisement was present.
+func updateAdvertisementFromProperties(adv *Advertisement, props objectProperties) (bool, error) {
+	updated := false
+
+	if v, ok := props["ManufacturerData"]; ok {
+		md, err := parseManufacturerData(v)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse manufacturer data: %v", err)
+		}
+		adv.ManufacturerData = md
+		updated = true
+	}
+
+	if v, ok := props["ServiceData"]; ok {
+		sd, err := parseServiceData(v)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse service data: %v", err)
+		}
+		adv.ServiceData = sd
+		updated = true
+	}
+
+	if v, ok := props["RSSI"]; ok {
+		var rssi int16
+		if err := v.Store(&rssi); err != nil {
+			return false, fmt.Errorf("failed to store RSSI variant to int16: %v", err)
+		}
+		adv.RSSI = &rssi
+		updated = true
+	}
+
+	if v, ok := props["TxPower"]; ok {
+		var txPower int16
+		if err := v.Store(&txPower); err != nil {
+			return false, fmt.Errorf("failed to store TxPower variant to int16: %v", err)
+		}
+		adv.TxPower = &txPower
+	}
+
+	if v, ok := props["Name"]; ok {
+		if err := v.Store(&adv.Name); err != nil {
+			return false, fmt.Errorf("failed to store Name variant to string: %v", err)
+		}
+	}
+
+	if v, ok := props["AddressType"]; ok {
+		if err := v.Store(&adv.AddressType); err != nil {
+			return false, fmt.Errorf("failed to store AddressType variant to string: %v", err)
+		}
+	}
+	return updated, nil
+}
+
 func parseAdvertisementFromProperties(props objectProperties) (Advertisement, error) {
-	var adv Advertisement
+	adv := Advertisement{
+		ManufacturerData: make(ManufacturerData),
+		ServiceData:      make(ServiceData),
+	}
 
 	// Get Address
 	addrVariant, ok := props["Address"]
@@ -119,65 +245,348 @@ func parseAdvertisementFromProperties(props objectProperties) (Advertisement, er
 	}
 	adv.Address = addr
 
-	// Get ManufacturerData
-	mdVariant, ok := props["ManufacturerData"]
-	if ok {
-		md, err := parseManufacturerData(mdVariant)
-		if err != nil {
-			return adv, fmt.Errorf("failed to parse manufacturer data: %v", err)
-		}
-		adv.ManufacturerData = md
-	} else {
-		adv.ManufacturerData = make(ManufacturerData)
+	if _, err := updateAdvertisementFromProperties(&adv, props); err != nil {
+		return adv, err
 	}
 	return adv, nil
 }
 
+// Options configure AdvListener.
+type Options struct {
+	// How AdvListener makes BlueZ scan for advertisements.
+	ScanMode ScanMode
+
+	// Patterns of advertisements to monitor when advertisement monitor is
+	// used. ScanModeAuto uses discovery when there are no patterns.
+	MonitorPatterns []MonitorPattern
+
+	// Filter set before starting discovery, nil to use BlueZ defaults.
+	DiscoveryFilter *DiscoveryFilter
+
+	// Whatever to power on the adapter before scanning, and again whenever
+	// it's powered off.
+	PowerOn bool
+
+	// Maximum number of devices whose last advertisement is cached, when
+	// exceeded, the least recently updated device is dropped. Zero means
+	// DefaultCacheSize.
+	CacheSize int
+
+	// Devices that didn't change for CacheTTL are dropped from the cache. Zero
+	// means DefaultCacheTTL.
+	CacheTTL time.Duration
+
+	// Whatever to remove devices dropped from the cache, either after
+	// CacheTTL, when it's full, or when it's cleared on restart of scanning,
+	// from BlueZ with org.bluez.Adapter1.RemoveDevice, so the bluetooth daemon
+	// doesn't accumulate all devices ever seen. Sensors, as decided by
+	// IsSensor, and paired, bonded, trusted or connected devices are never
+	// removed.
+	RemoveStaleDevices bool
+
+	// IsSensor returns whatever the advertisement comes from a sensor. Nil
+	// means no device is a sensor.
+	IsSensor func(adv *Advertisement) bool
+
+	// Maximum number of devices with advertisements waiting to be received
+	// from the Advertisements() channel. Zero means DefaultQueueSize.
+	QueueSize int
+}
+
+const (
+	// DefaultCacheSize is the default value of Options.CacheSize.
+	DefaultCacheSize = 1024
+	// DefaultCacheTTL is the default value of Options.CacheTTL.
+	DefaultCacheTTL = 10 * time.Minute
+)
+
+// DiscoveryFilter is passed to org.bluez.Adapter1.SetDiscoveryFilter, see
+// https://git.kernel.org/pub/scm/bluetooth/bluez.git/tree/doc/adapter-api.txt
+// Nil fields are not set.
+type DiscoveryFilter struct {
+	Transport     *string  // "auto", "bredr" or "le"
+	DuplicateData *bool    // Report every advertisement, not only changes
+	RSSI          *int16   // dBm, minimal RSSI of reported devices
+	UUIDs         []string // Service UUIDs that reported devices must advertise
+}
+
+// dbusFilter returns the filter in the SetDiscoveryFilter argument format.
+func (f *DiscoveryFilter) dbusFilter() map[string]dbus.Variant {
+	filter := make(map[string]dbus.Variant)
+	if f.Transport != nil {
+		filter["Transport"] = dbus.MakeVariant(*f.Transport)
+	}
+	if f.DuplicateData != nil {
+		filter["DuplicateData"] = dbus.MakeVariant(*f.DuplicateData)
+	}
+	if f.RSSI != nil {
+		filter["RSSI"] = dbus.MakeVariant(*f.RSSI)
+	}
+	if len(f.UUIDs) > 0 {
+		filter["UUIDs"] = dbus.MakeVariant(f.UUIDs)
+	}
+	return filter
+}
+
 // AdvListener uses DBUS Bluez interface to listen for BLE advertisements and
-// returns them via Advertisements() channel. When the Advertisements() channel
-// is closed, the Err field contains the error.
+// returns them via Advertisements() channel. It implements Listener.
+//
+// AdvListener survives restarts of the bluetooth daemon, removal of the
+// adapter and loss of the system bus connection: it stops scanning and starts
+// it again when the adapter is back.
 type AdvListener struct {
-	adapter  dbus.BusObject
-	conn     *dbus.Conn
-	m        sync.Mutex // Guards advCache and Err
-	advCache map[dbus.ObjectPath]Advertisement
-	signals  chan *dbus.Signal
-	results  chan Advertisement
-	Err      error
+	adapterName string
+	adapterPath dbus.ObjectPath
+	options     *Options
+	useMonitor  bool
+	m           sync.Mutex // Guards conn, adapter, advCache, monitorApp and err
+	conn        *dbus.Conn
+	adapter     dbus.BusObject
+	advCache    *advCache
+	cacheTTL    time.Duration
+	monitorApp  dbus.ObjectPath // Registered advertisement monitor, if any
+	scanStop    chan struct{}   // Closed to stop scanning, nil when not scanning
+	discCheck   chan struct{}   // Makes discovery loop check discovering status
+	queue       *advQueue
+	done        chan struct{}
+	closeOnce   sync.Once
+	err         error
 }
 
+var _ Listener = (*AdvListener)(nil)
+
 // Advertisements returns a channel that AdvListener publishes advertisements on.
 func (l *AdvListener) Advertisements() <-chan Advertisement {
-	return l.results
+	return l.queue.results
 }
 
-func (l *AdvListener) publishAdvertisement(objPath dbus.ObjectPath, adv Advertisement) {
+// Dropped implements Listener.
+func (l *AdvListener) Dropped() uint64 {
+	return l.queue.droppedCount()
+}
+
+// Err implements Listener.
+func (l *AdvListener) Err() error {
 	l.m.Lock()
 	defer l.m.Unlock()
-	l.advCache[objPath] = adv
-	if len(adv.ManufacturerData) > 0 {
-		l.results <- adv
+	return l.err
+}
+
+// Close implements Listener.
+func (l *AdvListener) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.done)
+		l.m.Lock()
+		conn, adapter, monitorApp := l.conn, l.adapter, l.monitorApp
+		l.m.Unlock()
+		if monitorApp != "" {
+			// Best effort, BlueZ drops the monitor when connection is closed anyway.
+			adapter.Call("org.bluez.AdvertisementMonitorManager1.UnregisterMonitor", 0, monitorApp)
+		}
+		err = conn.Close()
+	})
+	return err
+}
+
+func (l *AdvListener) publishAdvertisement(objPath dbus.ObjectPath, adv Advertisement) {
+	adv.Adapter = l.adapterName
+	adv.ReceivedAt = time.Now()
+	l.m.Lock()
+	evicted := l.advCache.put(objPath, adv, adv.ReceivedAt)
+	l.m.Unlock()
+	if evicted != nil {
+		l.removeStaleDevices([]*advCacheEntry{evicted})
+	}
+	if len(adv.ManufacturerData) > 0 || len(adv.ServiceData) > 0 {
+		l.queue.push(adv)
 	}
 }
 
 func (l *AdvListener) setError(err error) {
+	select {
+	case <-l.done:
+		// Errors caused by closing the listener are expected.
+		return
+	default:
+	}
 	l.m.Lock()
-	defer l.m.Unlock()
-	if l.Err == nil {
-		l.Err = err
+	if l.err == nil {
+		l.err = err
 	}
-	if err := l.conn.Close(); err != nil {
+	l.m.Unlock()
+	if err := l.Close(); err != nil {
 		log.Printf("Closing system bus connection failed: %v", err)
 	}
 }
 
+// clearCache drops all cached advertisements, it should be done whenever the
+// cache might be stale. The dropped devices are removed from BlueZ if
+// requested and the adapter is still there.
+func (l *AdvListener) clearCache() {
+	l.m.Lock()
+	cleared := l.advCache.clear()
+	l.m.Unlock()
+	if len(cleared) > 0 && adapterAvailable(l.adapter) {
+		l.removeStaleDevices(cleared)
+	}
+}
+
+// expireCache drops devices that didn't change for cacheTTL from the cache
+// and removes the stale ones from BlueZ if requested.
+func (l *AdvListener) expireCache() {
+	l.m.Lock()
+	expired := l.advCache.expire(time.Now().Add(-l.cacheTTL))
+	l.m.Unlock()
+	l.removeStaleDevices(expired)
+}
+
+// removeStaleDevices removes devices dropped from the cache from BlueZ if
+// requested, so its device list doesn't grow without bound. Sensors are kept.
+func (l *AdvListener) removeStaleDevices(entries []*advCacheEntry) {
+	if !l.options.RemoveStaleDevices {
+		return
+	}
+	for _, entry := range entries {
+		if l.options.IsSensor != nil && l.options.IsSensor(&entry.adv) {
+			continue
+		}
+		if err := l.removeStaleDevice(entry.path); err != nil {
+			log.Printf("Failed to remove stale device %s: %v", entry.path, err)
+		}
+	}
+}
+
+// removeStaleDevice removes the device from BlueZ unless it's in use.
+func (l *AdvListener) removeStaleDevice(objPath dbus.ObjectPath) error {
+	var props objectProperties
+	if err := l.conn.Object("org.bluez", objPath).Call("org.freedesktop.DBus.Properties.GetAll", 0, "org.bluez.Device1").Store(&props); err != nil {
+		return fmt.Errorf("failed to get all properties: %v", err)
+	}
+	for _, name := range []string{"Paired", "Bonded", "Trusted", "Connected"} {
+		if v, ok := props[name].Value().(bool); ok && v {
+			return nil
+		}
+	}
+	if err := l.adapter.Call("org.bluez.Adapter1.RemoveDevice", 0, objPath).Err; err != nil {
+		return fmt.Errorf("failed to call RemoveDevice: %v", err)
+	}
+	return nil
+}
+
+// adapterAvailable returns whatever the bluetooth daemon is running and the
+// adapter exists.
+func adapterAvailable(adapter dbus.BusObject) bool {
+	_, err := adapter.GetProperty("org.bluez.Adapter1.Address")
+	return err == nil
+}
+
+// powerOn powers on the adapter if it's powered off.
+func (l *AdvListener) powerOn(adapter dbus.BusObject) error {
+	var powered bool
+	if err := adapter.StoreProperty("org.bluez.Adapter1.Powered", &powered); err != nil {
+		return fmt.Errorf("failed to read powered status: %v", err)
+	}
+	if powered {
+		return nil
+	}
+	blockedErr := fmt.Errorf("adapter is blocked by rfkill, unblock it with `rfkill unblock bluetooth`")
+	// PowerState is available only in newer BlueZ versions.
+	var powerState string
+	if err := adapter.StoreProperty("org.bluez.Adapter1.PowerState", &powerState); err == nil && powerState == "off-blocked" {
+		return blockedErr
+	}
+	log.Printf("Powering on Bluetooth adapter %s", l.adapterName)
+	if err := adapter.SetProperty("org.bluez.Adapter1.Powered", dbus.MakeVariant(true)); err != nil {
+		if strings.Contains(err.Error(), "rfkill") || strings.Contains(err.Error(), "Blocked") {
+			return blockedErr
+		}
+		return fmt.Errorf("failed to power on: %v", err)
+	}
+	return nil
+}
+
+// startScanning starts the discovery or the advertisement monitor, unless it's
+// already running. It must be called only from the serve goroutine.
+func (l *AdvListener) startScanning() {
+	if l.scanStop != nil {
+		return
+	}
+	if l.options.PowerOn {
+		if err := l.powerOn(l.adapter); err != nil {
+			l.setError(fmt.Errorf("failed to power on Bluetooth adapter %s: %v", l.adapterName, err))
+			return
+		}
+	}
+	l.scanStop = make(chan struct{})
+	if l.useMonitor {
+		err := l.registerMonitor(l.options.MonitorPatterns)
+		if err == nil {
+			log.Printf("Using advertisement monitor on %s", l.adapterName)
+			return
+		}
+		if l.options.ScanMode == ScanModeMonitor {
+			l.setError(err)
+			return
+		}
+		log.Printf("Falling back to discovery on %s: %v", l.adapterName, err)
+	}
+	l.discCheck = make(chan struct{}, 1)
+	go l.discoveryLoop(l.adapter, l.scanStop, l.discCheck)
+}
+
+// stopScanning marks that scanning stopped, because the bluetooth daemon or
+// adapter are gone, or it's about to be restarted. It must be called only from
+// the serve goroutine.
+func (l *AdvListener) stopScanning() {
+	if l.scanStop != nil {
+		close(l.scanStop)
+		l.scanStop = nil
+		l.discCheck = nil
+	}
+	l.m.Lock()
+	monitorApp := l.monitorApp
+	l.monitorApp = ""
+	l.m.Unlock()
+	if monitorApp != "" && adapterAvailable(l.adapter) {
+		l.adapter.Call("org.bluez.AdvertisementMonitorManager1.UnregisterMonitor", 0, monitorApp)
+	}
+	l.clearCache()
+}
+
+// handleAdapterPropertiesChanged powers on the adapter again when it's powered
+// off and PowerOn option is set, and makes the discovery loop restart
+// discovery when it stops.
+func (l *AdvListener) handleAdapterPropertiesChanged(changed *propertiesChangedSignal) {
+	if v, ok := changed.ChangedProperties["Powered"]; ok && l.options.PowerOn {
+		if powered, ok := v.Value().(bool); ok && !powered {
+			log.Printf("Bluetooth adapter %s was powered off", l.adapterName)
+			l.stopScanning()
+			l.startScanning()
+			return
+		}
+	}
+	if v, ok := changed.ChangedProperties["Discovering"]; ok && l.discCheck != nil {
+		if discovering, ok := v.Value().(bool); ok && !discovering {
+			select {
+			case l.discCheck <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
 func (l *AdvListener) handlePropertiesChanged(objPath dbus.ObjectPath, changed *propertiesChangedSignal) error {
+	if objPath == l.adapterPath && changed.InterfaceName == "org.bluez.Adapter1" {
+		l.handleAdapterPropertiesChanged(changed)
+		return nil
+	}
 	if changed.InterfaceName != "org.bluez.Device1" {
 		return nil
 	}
 	publish := false
 	l.m.Lock()
-	adv, ok := l.advCache[objPath]
+	adv, ok := l.advCache.get(objPath)
 	l.m.Unlock()
 	if !ok {
 		var props objectProperties
@@ -192,26 +601,43 @@ func (l *AdvListener) handlePropertiesChanged(objPath dbus.ObjectPath, changed *
 		publish = true
 	}
 
-	if v, ok := changed.ChangedProperties["ManufacturerData"]; ok {
-		md, err := parseManufacturerData(v)
-		if err != nil {
-			return fmt.Errorf("failed to parse manufacturer data: %v", err)
-		}
-		adv.ManufacturerData = md
-		publish = true
+	updated, err := updateAdvertisementFromProperties(&adv, changed.ChangedProperties)
+	if err != nil {
+		return err
 	}
-
-	if _, ok := changed.ChangedProperties["RSSI"]; ok {
-		publish = true
+	publish = publish || updated
+
+	for _, name := range changed.InvalidatedProperties {
+		switch name {
+		case "RSSI":
+			adv.RSSI = nil
+		case "TxPower":
+			adv.TxPower = nil
+		}
 	}
 
 	if publish {
 		l.publishAdvertisement(objPath, adv)
+	} else {
+		// The device is still around, so it's not stale.
+		l.m.Lock()
+		evicted := l.advCache.put(objPath, adv, time.Now())
+		l.m.Unlock()
+		if evicted != nil {
+			l.removeStaleDevices([]*advCacheEntry{evicted})
+		}
 	}
 	return nil
 }
 
 func (l *AdvListener) handleInterfacesAdded(added *interfacesAddedSignal) error {
+	if added.ObjectPath == l.adapterPath {
+		if _, ok := added.InterfacesAndProperties["org.bluez.Adapter1"]; ok {
+			log.Printf("Bluetooth adapter %s appeared, starting scanning", l.adapterName)
+			l.startScanning()
+		}
+		return nil
+	}
 	deviceProps, ok := added.InterfacesAndProperties["org.bluez.Device1"]
 	if !ok {
 		return nil
@@ -224,30 +650,103 @@ func (l *AdvListener) handleInterfacesAdded(added *interfacesAddedSignal) error
 	return nil
 }
 
-func (l *AdvListener) signalHandlerLoop() {
-	defer close(l.results)
+func (l *AdvListener) handleInterfacesRemoved(removed *interfacesRemovedSignal) {
+	if removed.ObjectPath == l.adapterPath {
+		for _, iface := range removed.Interfaces {
+			if iface == "org.bluez.Adapter1" {
+				log.Printf("Bluetooth adapter %s disappeared, waiting for it to come back", l.adapterName)
+				l.stopScanning()
+			}
+		}
+		return
+	}
+	l.m.Lock()
+	l.advCache.remove(removed.ObjectPath)
+	l.m.Unlock()
+}
+
+func (l *AdvListener) handleNameOwnerChanged(name, oldOwner, newOwner string) {
+	if name != "org.bluez" {
+		return
+	}
+	if newOwner == "" {
+		log.Printf("Bluetooth daemon disappeared, waiting for it to come back")
+		l.stopScanning()
+	} else if adapterAvailable(l.adapter) {
+		// Usually the adapter appears later with InterfacesAdded signal.
+		l.startScanning()
+	}
+}
+
+// serve handles signals from the system bus connection until it's closed.
+func (l *AdvListener) serve(conn *dbus.Conn) error {
+	l.m.Lock()
+	l.conn = conn
+	l.adapter = conn.Object("org.bluez", l.adapterPath)
+	l.m.Unlock()
+	defer conn.Close()
+	defer l.stopScanning()
+
+	// The connection could have been closed before it was set above.
+	select {
+	case <-l.done:
+		return nil
+	default:
+	}
+
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
 
-	if err := l.conn.AddMatchSignal(
+	if err := conn.AddMatchSignal(
 		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
 		dbus.WithMatchMember("PropertiesChanged"),
-		dbus.WithMatchPathNamespace(l.adapter.Path())); err != nil {
-		l.setError(fmt.Errorf("failed to add matcher for PropertiesChanged signal: %v", err))
-		return
+		dbus.WithMatchPathNamespace(l.adapterPath)); err != nil {
+		return fmt.Errorf("failed to add matcher for PropertiesChanged signal: %v", err)
 	}
-	if err := l.conn.AddMatchSignal(
+	if err := conn.AddMatchSignal(
 		dbus.WithMatchInterface("org.freedesktop.DBus.ObjectManager"),
 		dbus.WithMatchMember("InterfacesRemoved")); err != nil {
-		l.setError(fmt.Errorf("failed to add matcher for InterfacesRemoved signal: %v", err))
-		return
+		return fmt.Errorf("failed to add matcher for InterfacesRemoved signal: %v", err)
 	}
-	if err := l.conn.AddMatchSignal(
+	if err := conn.AddMatchSignal(
 		dbus.WithMatchInterface("org.freedesktop.DBus.ObjectManager"),
 		dbus.WithMatchMember("InterfacesAdded")); err != nil {
-		l.setError(fmt.Errorf("failed to add matcher for InterfacesAdded signal: %v", err))
-		return
+		return fmt.Errorf("failed to add matcher for InterfacesAdded signal: %v", err)
+	}
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg(0, "org.bluez")); err != nil {
+		return fmt.Errorf("failed to add matcher for NameOwnerChanged signal: %v", err)
+	}
+
+	// Signals are subscribed, so we will notice if the adapter appears later.
+	if adapterAvailable(l.adapter) {
+		l.startScanning()
+	} else {
+		log.Printf("Bluetooth adapter %s is not available, waiting for it", l.adapterName)
 	}
 
-	for signal := range l.signals {
+	// Devices are dropped from the cache between cacheTTL and 1.5*cacheTTL
+	// after they were last updated.
+	expireTicker := time.NewTicker(l.cacheTTL / 2)
+	defer expireTicker.Stop()
+
+	for {
+		var signal *dbus.Signal
+		select {
+		case signal = <-signals:
+		case <-expireTicker.C:
+			l.expireCache()
+			continue
+		case <-l.done:
+			return nil
+		}
+		if signal == nil {
+			// The connection was closed, it closes the signals channel.
+			return fmt.Errorf("system bus connection closed")
+		}
 		switch signal.Name {
 		case "org.freedesktop.DBus.Properties.PropertiesChanged":
 			var changed propertiesChangedSignal
@@ -283,20 +782,56 @@ func (l *AdvListener) signalHandlerLoop() {
 				log.Printf("Failed to parse InterfacesRemoved signal: %v", err)
 				break
 			}
-			l.m.Lock()
-			delete(l.advCache, removed.ObjectPath)
-			l.m.Unlock()
+			l.handleInterfacesRemoved(&removed)
+		case "org.freedesktop.DBus.NameOwnerChanged":
+			var name, oldOwner, newOwner string
+			if err := dbus.Store(signal.Body, &name, &oldOwner, &newOwner); err != nil {
+				log.Printf("Failed to parse NameOwnerChanged signal: %v", err)
+				break
+			}
+			l.handleNameOwnerChanged(name, oldOwner, newOwner)
+		}
+	}
+}
+
+// run serves the system bus connection and reconnects when it's lost, until
+// the listener is closed.
+func (l *AdvListener) run(conn *dbus.Conn) {
+	for {
+		err := l.serve(conn)
+		select {
+		case <-l.done:
+			return
+		default:
+		}
+		log.Printf("Lost system bus connection, reconnecting: %v", err)
+		conn = nil
+		for retryNum := 1; conn == nil; retryNum++ {
+			select {
+			case <-time.After(backoff.Exponential(retryNum, time.Second, time.Minute, 2.0)):
+			case <-l.done:
+				return
+			}
+			if conn, err = dbus.ConnectSystemBus(); err != nil {
+				log.Printf("Failed to connect to system bus: %v", err)
+			}
 		}
 	}
 }
 
 // Issues a call to org.bluez.Adapter1.StartDiscovery retrying the
 // "Resource Not Read" error that can happen transitively when the Bluetooth
-// device is still powering on.
-func (l *AdvListener) callAdapterStartDiscoveryWithRetry() error {
+// device is still powering on. The discovery filter is set first, as it's
+// dropped by BlueZ together with the discovery session.
+func (l *AdvListener) callAdapterStartDiscoveryWithRetry(adapter dbus.BusObject) error {
 	for retryNum := 0; true; retryNum++ {
 		time.Sleep(backoff.Exponential(retryNum, time.Second, time.Second*5, 2.0))
-		call := l.adapter.Call("org.bluez.Adapter1.StartDiscovery", 0)
+		if l.options.DiscoveryFilter != nil {
+			if err := adapter.Call("org.bluez.Adapter1.SetDiscoveryFilter", 0, l.options.DiscoveryFilter.dbusFilter()).Err; err != nil {
+				return fmt.Errorf("failed to set discovery filter: %v", err)
+			}
+		}
+		call := adapter.Call("org.bluez.Adapter1.StartDiscovery", 0)
 		if call.Err == nil {
 			return nil
 		}
@@ -308,37 +843,75 @@ func (l *AdvListener) callAdapterStartDiscoveryWithRetry() error {
 	panic("unreachable")
 }
 
-func (l *AdvListener) startDiscoveryLoop() {
+// discoveryError handles error in the discovery loop. When the adapter is not
+// available, the error is expected and scanning will be restarted when it's
+// back, otherwise it's fatal.
+func (l *AdvListener) discoveryError(adapter dbus.BusObject, stop <-chan struct{}, err error) {
+	select {
+	case <-stop:
+		return
+	default:
+	}
+	if !adapterAvailable(adapter) {
+		log.Printf("Discovery on %s stopped, adapter is not available: %v", l.adapterName, err)
+		return
+	}
+	var powered bool
+	if adapter.StoreProperty("org.bluez.Adapter1.Powered", &powered) == nil && !powered {
+		err = fmt.Errorf("%v, adapter is powered off", err)
+	}
+	l.setError(err)
+}
+
+func (l *AdvListener) discoveryLoop(adapter dbus.BusObject, stop <-chan struct{}, check <-chan struct{}) {
 	for {
-		if err := l.callAdapterStartDiscoveryWithRetry(); err != nil {
-			l.setError(fmt.Errorf("failed to start discovery: %v", err))
+		if err := l.callAdapterStartDiscoveryWithRetry(adapter); err != nil {
+			l.discoveryError(adapter, stop, fmt.Errorf("failed to start discovery: %v", err))
 			return
 		}
 		// This block is responsible for making sure that adapter is constantly
 		// in the discovering mode. The single StartDiscovery should enable discovering
-		// indefinitely in the bluetooth daemon but it's possible that bluetooth deamon
-		// itself will be restarted/will crash and stop discovery.
-		// Instead of having this loop we could also listen to proper signals and detect
-		// changes that way, but this way it seems easier and good enough.
+		// indefinitely in the bluetooth daemon but something else might stop it.
+		// Changes of the Discovering property are signaled via check, and the
+		// periodic check is only a safety net in case the signal is missed.
+		// Restarts of the bluetooth daemon and the adapter are detected by signals
+		// in serve.
 		for discovering := true; discovering; {
-			time.Sleep(time.Minute * 4)
-			err := l.adapter.StoreProperty("org.bluez.Adapter1.Discovering", &discovering)
+			select {
+			case <-time.After(time.Minute * 4):
+			case <-check:
+			case <-stop:
+				return
+			}
+			err := adapter.StoreProperty("org.bluez.Adapter1.Discovering", &discovering)
 			if err != nil {
-				l.setError(fmt.Errorf("failed to read discovering status: %v", err))
+				l.discoveryError(adapter, stop, fmt.Errorf("failed to read discovering status: %v", err))
 				return
 			}
 		}
 		// We should clear the map becuase cache might be stale.
-		l.m.Lock()
-		l.advCache = make(map[dbus.ObjectPath]Advertisement)
-		l.m.Unlock()
+		l.clearCache()
 
 		log.Printf("Discovering stopped, restating...")
 	}
 }
 
-// NewAdvListener creates new AdvListener and starts listening.
-func NewAdvListener(adapterName string) (*AdvListener, error) {
+// NewAdvListener creates new AdvListener and starts listening. The listener
+// is closed when ctx is cancelled. Options can be nil to use the defaults.
+func NewAdvListener(ctx context.Context, adapterName string, options *Options) (*AdvListener, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	cacheSize, cacheTTL, queueSize := options.CacheSize, options.CacheTTL, options.QueueSize
+	if cacheSize <= 0 {
+		cacheSize = DefaultCacheSize
+	}
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultCacheTTL
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
 	conn, err := dbus.ConnectSystemBus()
 	if err != nil {
 		return nil, fmt.Errorf("failed to conntect to system bus: %v", err)
@@ -349,21 +922,48 @@ func NewAdvListener(adapterName string) (*AdvListener, error) {
 	// Let's return that adapter doesn't exist as clear error before calling StartDiscovery.
 	var managedObjects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
 	if err := conn.Object("org.bluez", dbus.ObjectPath("/")).Call("org.freedesktop.DBus.ObjectManager.GetManagedObjects", 0).Store(&managedObjects); err != nil {
+		conn.Close()
 		return nil, fmt.Errorf("failed to get list of Bluetooth adapters: %v", err)
 	}
 	if _, ok := managedObjects[adapterPath]; !ok {
+		conn.Close()
 		return nil, fmt.Errorf("requested to listen on Bluetooth adapter '%s', but it doesn't exist", adapterName)
 	}
 
 	l := &AdvListener{
-		adapter:  conn.Object("org.bluez", adapterPath),
-		conn:     conn,
-		advCache: make(map[dbus.ObjectPath]Advertisement),
-		signals:  make(chan *dbus.Signal, 10),
-		results:  make(chan Advertisement, 10),
-	}
-	conn.Signal(l.signals)
-	go l.signalHandlerLoop()
-	go l.startDiscoveryLoop()
+		adapterName: adapterName,
+		adapterPath: adapterPath,
+		options:     options,
+		conn:        conn,
+		adapter:     conn.Object("org.bluez", adapterPath),
+		advCache:    newAdvCache(cacheSize),
+		cacheTTL:    cacheTTL,
+		queue:       newAdvQueue(queueSize),
+		done:        make(chan struct{}),
+	}
+
+	switch options.ScanMode {
+	case ScanModeMonitor:
+		if !l.monitorSupported() {
+			conn.Close()
+			return nil, fmt.Errorf("Bluetooth adapter '%s' doesn't support advertisement monitors", adapterName)
+		}
+		l.useMonitor = true
+	case ScanModeAuto:
+		l.useMonitor = len(options.MonitorPatterns) > 0 && l.monitorSupported()
+	}
+	if l.useMonitor && options.DiscoveryFilter != nil {
+		log.Printf("Using advertisement monitor on %s, discovery filter is ignored", adapterName)
+	}
+
+	go l.run(conn)
+	go l.queue.deliver(l.done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			l.Close()
+		case <-l.done:
+		}
+	}()
 	return l, nil
 }
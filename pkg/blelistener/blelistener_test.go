@@ -0,0 +1,125 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blelistener
+
+import (
+	"net"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+func int16Ptr(v int16) *int16 { return &v }
+
+func TestParseAdvertisementFromProperties(t *testing.T) {
+	props := objectProperties{
+		"Address":     dbus.MakeVariant("A4:C1:38:01:02:03"),
+		"AddressType": dbus.MakeVariant("public"),
+		"Name":        dbus.MakeVariant("ATC_010203"),
+		"RSSI":        dbus.MakeVariant(int16(-67)),
+		"TxPower":     dbus.MakeVariant(int16(4)),
+		"ManufacturerData": dbus.MakeVariant(map[uint16]dbus.Variant{
+			0x0499: dbus.MakeVariant([]byte{0x05, 0x12}),
+		}),
+		"ServiceData": dbus.MakeVariant(map[string]dbus.Variant{
+			"0000181A-0000-1000-8000-00805F9B34FB": dbus.MakeVariant([]byte{0xa4, 0xc1}),
+		}),
+	}
+	adv, err := parseAdvertisementFromProperties(props)
+	if err != nil {
+		t.Fatalf("Failed to parse properties: %v", err)
+	}
+	expected := Advertisement{
+		Address:          net.HardwareAddr{0xa4, 0xc1, 0x38, 0x01, 0x02, 0x03},
+		AddressType:      "public",
+		Name:             "ATC_010203",
+		ManufacturerData: ManufacturerData{0x0499: {0x05, 0x12}},
+		ServiceData:      ServiceData{ServiceDataUUID(0x181a): {0xa4, 0xc1}},
+		RSSI:             int16Ptr(-67),
+		TxPower:          int16Ptr(4),
+	}
+	if diff := cmp.Diff(adv, expected); diff != "" {
+		t.Errorf("unexpected difference:\n%v", diff)
+	}
+
+	updated, err := updateAdvertisementFromProperties(&adv, objectProperties{
+		"RSSI": dbus.MakeVariant(int16(-80)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to update properties: %v", err)
+	}
+	if !updated {
+		t.Errorf("RSSI change didn't mark advertisement as updated")
+	}
+	if *adv.RSSI != -80 {
+		t.Errorf("RSSI not updated: value %d, expected %d", *adv.RSSI, -80)
+	}
+
+	updated, err = updateAdvertisementFromProperties(&adv, objectProperties{
+		"Name": dbus.MakeVariant("renamed"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to update properties: %v", err)
+	}
+	if updated {
+		t.Errorf("Name change marked advertisement as updated")
+	}
+}
+
+func TestParseAdvertisementFromPropertiesInvalid(t *testing.T) {
+	cases := []struct {
+		name  string
+		props objectProperties
+	}{
+		{"no address", objectProperties{}},
+		{"invalid RSSI", objectProperties{
+			"Address": dbus.MakeVariant("A4:C1:38:01:02:03"),
+			"RSSI":    dbus.MakeVariant("strong"),
+		}},
+		{"invalid service data", objectProperties{
+			"Address":     dbus.MakeVariant("A4:C1:38:01:02:03"),
+			"ServiceData": dbus.MakeVariant(map[string]dbus.Variant{"uuid": dbus.MakeVariant(int32(1))}),
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := parseAdvertisementFromProperties(tc.props); err == nil {
+				t.Fatalf("Expected error, got success")
+			}
+		})
+	}
+}
+
+func TestDiscoveryFilter(t *testing.T) {
+	transport := "le"
+	duplicateData := true
+	filter := &DiscoveryFilter{
+		Transport:     &transport,
+		DuplicateData: &duplicateData,
+		RSSI:          int16Ptr(-90),
+	}
+	expected := map[string]dbus.Variant{
+		"Transport":     dbus.MakeVariant("le"),
+		"DuplicateData": dbus.MakeVariant(true),
+		"RSSI":          dbus.MakeVariant(int16(-90)),
+	}
+	if diff := cmp.Diff(filter.dbusFilter(), expected, cmp.Comparer(func(a, b dbus.Variant) bool {
+		return a.String() == b.String()
+	})); diff != "" {
+		t.Errorf("unexpected difference:\n%v", diff)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blelistener
+
+import (
+	"container/list"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+type advCacheEntry struct {
+	path    dbus.ObjectPath
+	adv     Advertisement
+	updated time.Time
+}
+
+// advCache is a bounded cache of the last advertisement of every device. When
+// it's full, the least recently updated entry is evicted.
+type advCache struct {
+	maxSize int
+	entries map[dbus.ObjectPath]*list.Element
+	order   *list.List // Of *advCacheEntry, the most recently updated first
+}
+
+func newAdvCache(maxSize int) *advCache {
+	return &advCache{
+		maxSize: maxSize,
+		entries: make(map[dbus.ObjectPath]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *advCache) get(path dbus.ObjectPath) (Advertisement, bool) {
+	if e, ok := c.entries[path]; ok {
+		return e.Value.(*advCacheEntry).adv, true
+	}
+	return Advertisement{}, false
+}
+
+// put stores the advertisement of the device and returns the entry evicted to
+// make space for it, if any.
+func (c *advCache) put(path dbus.ObjectPath, adv Advertisement, now time.Time) *advCacheEntry {
+	if e, ok := c.entries[path]; ok {
+		entry := e.Value.(*advCacheEntry)
+		entry.adv, entry.updated = adv, now
+		c.order.MoveToFront(e)
+		return nil
+	}
+	c.entries[path] = c.order.PushFront(&advCacheEntry{path, adv, now})
+	if c.order.Len() > c.maxSize {
+		evicted := c.order.Back().Value.(*advCacheEntry)
+		c.remove(evicted.path)
+		return evicted
+	}
+	return nil
+}
+
+func (c *advCache) remove(path dbus.ObjectPath) {
+	if e, ok := c.entries[path]; ok {
+		c.order.Remove(e)
+		delete(c.entries, path)
+	}
+}
+
+func (c *advCache) len() int {
+	return c.order.Len()
+}
+
+// clear removes all entries and returns them.
+func (c *advCache) clear() []*advCacheEntry {
+	var cleared []*advCacheEntry
+	for e := c.order.Front(); e != nil; e = e.Next() {
+		cleared = append(cleared, e.Value.(*advCacheEntry))
+	}
+	c.entries = make(map[dbus.ObjectPath]*list.Element)
+	c.order.Init()
+	return cleared
+}
+
+// expire removes entries that weren't updated since the given time and returns
+// them.
+func (c *advCache) expire(before time.Time) []*advCacheEntry {
+	var expired []*advCacheEntry
+	for e := c.order.Back(); e != nil && e.Value.(*advCacheEntry).updated.Before(before); e = c.order.Back() {
+		entry := e.Value.(*advCacheEntry)
+		c.remove(entry.path)
+		expired = append(expired, entry)
+	}
+	return expired
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blelistener
+
+import (
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestAdvCache(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	c := newAdvCache(2)
+	for i, e := range []struct {
+		path dbus.ObjectPath
+		name string
+	}{{"/dev_1", "1"}, {"/dev_2", "2"}, {"/dev_1", "1b"}} {
+		if evicted := c.put(e.path, Advertisement{Name: e.name}, start.Add(time.Duration(i)*time.Second)); evicted != nil {
+			t.Errorf("Unexpected eviction of %s when putting %s", evicted.path, e.name)
+		}
+	}
+	evicted := c.put("/dev_3", Advertisement{Name: "3"}, start.Add(3*time.Second))
+	if evicted == nil || evicted.path != "/dev_2" || evicted.adv.Name != "2" {
+		t.Errorf("Least recently updated entry was not evicted, got %v", evicted)
+	}
+	if _, ok := c.get("/dev_2"); ok {
+		t.Errorf("Evicted entry is still in cache")
+	}
+	if adv, ok := c.get("/dev_1"); !ok || adv.Name != "1b" {
+		t.Errorf("Got %v, %t for updated entry", adv, ok)
+	}
+	if c.len() != 2 {
+		t.Errorf("Cache has %d entries, expected 2", c.len())
+	}
+
+	expired := c.expire(start.Add(3 * time.Second))
+	if len(expired) != 1 || expired[0].path != "/dev_1" {
+		t.Errorf("Unexpected expired entries: %v", expired)
+	}
+	if _, ok := c.get("/dev_3"); !ok || c.len() != 1 {
+		t.Errorf("Not expired entry was removed")
+	}
+
+	c.remove("/dev_3")
+	if c.len() != 0 {
+		t.Errorf("Cache has %d entries after remove, expected 0", c.len())
+	}
+
+	c.put("/dev_4", Advertisement{Name: "4"}, start)
+	c.put("/dev_5", Advertisement{Name: "5"}, start)
+	if cleared := c.clear(); len(cleared) != 2 || c.len() != 0 {
+		t.Errorf("Unexpected cleared entries %v, %d entries left", cleared, c.len())
+	}
+}
@@ -0,0 +1,438 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blelistener
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:dir=%DIR%</listen>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// startBus starts a private D-Bus daemon and makes it the system bus for the
+// test. It returns the bus address and a function stopping the daemon.
+func startBus(t *testing.T) (string, func()) {
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not available")
+	}
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "bus.conf")
+	if err := os.WriteFile(configPath, []byte(strings.ReplaceAll(busConfig, "%DIR%", dir)), 0644); err != nil {
+		t.Fatalf("Failed to write bus config: %v", err)
+	}
+	cmd := exec.Command(daemon, "--nofork", "--print-address", "--config-file="+configPath)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("Failed to get dbus-daemon stdout: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start dbus-daemon: %v", err)
+	}
+	var stopOnce sync.Once
+	stop := func() {
+		stopOnce.Do(func() {
+			cmd.Process.Kill()
+			cmd.Wait()
+		})
+	}
+	t.Cleanup(stop)
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("Failed to read dbus-daemon address: %v", err)
+	}
+	address = strings.TrimSpace(address)
+	t.Setenv("DBUS_SYSTEM_BUS_ADDRESS", address)
+	return address, stop
+}
+
+// fakeBlueZ implements the subset of the BlueZ D-Bus API used by AdvListener.
+type fakeBlueZ struct {
+	t                   *testing.T
+	conn                *dbus.Conn
+	m                   sync.Mutex
+	adapters            map[dbus.ObjectPath]*prop.Properties
+	startDiscoveryCalls int
+	removedDevices      []dbus.ObjectPath
+}
+
+func (b *fakeBlueZ) GetManagedObjects() (map[dbus.ObjectPath]map[string]map[string]dbus.Variant, *dbus.Error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	objects := make(map[dbus.ObjectPath]map[string]map[string]dbus.Variant)
+	for path := range b.adapters {
+		objects[path] = map[string]map[string]dbus.Variant{"org.bluez.Adapter1": {}}
+	}
+	return objects, nil
+}
+
+func (b *fakeBlueZ) StartDiscovery() *dbus.Error {
+	b.m.Lock()
+	b.startDiscoveryCalls++
+	var adapters []*prop.Properties
+	for _, props := range b.adapters {
+		adapters = append(adapters, props)
+	}
+	b.m.Unlock()
+	for _, props := range adapters {
+		props.SetMust("org.bluez.Adapter1", "Discovering", true)
+	}
+	return nil
+}
+
+func (b *fakeBlueZ) RemoveDevice(path dbus.ObjectPath) *dbus.Error {
+	b.conn.Export(nil, path, "org.freedesktop.DBus.Properties")
+	b.m.Lock()
+	b.removedDevices = append(b.removedDevices, path)
+	b.m.Unlock()
+	b.emit("org.freedesktop.DBus.ObjectManager.InterfacesRemoved", path, []string{"org.bluez.Device1"})
+	return nil
+}
+
+func (b *fakeBlueZ) SetDiscoveryFilter(filter map[string]dbus.Variant) *dbus.Error {
+	return nil
+}
+
+func newFakeBlueZ(t *testing.T, address string) *fakeBlueZ {
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("Failed to connect to bus: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	b := &fakeBlueZ{t: t, conn: conn, adapters: make(map[dbus.ObjectPath]*prop.Properties)}
+	if err := conn.Export(b, "/", "org.freedesktop.DBus.ObjectManager"); err != nil {
+		t.Fatalf("Failed to export object manager: %v", err)
+	}
+	if reply, err := conn.RequestName("org.bluez", dbus.NameFlagDoNotQueue); err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("Failed to request name: %v", err)
+	}
+	return b
+}
+
+func (b *fakeBlueZ) addAdapter(path dbus.ObjectPath, powered bool) {
+	if err := b.conn.Export(b, path, "org.bluez.Adapter1"); err != nil {
+		b.t.Fatalf("Failed to export adapter: %v", err)
+	}
+	props, err := prop.Export(b.conn, path, prop.Map{"org.bluez.Adapter1": {
+		"Address":     {Value: "00:11:22:33:44:55"},
+		"Discovering": {Value: false, Emit: prop.EmitTrue},
+		"Powered":     {Value: powered, Writable: true, Emit: prop.EmitTrue},
+	}})
+	if err != nil {
+		b.t.Fatalf("Failed to export adapter properties: %v", err)
+	}
+	b.m.Lock()
+	b.adapters[path] = props
+	b.m.Unlock()
+	b.emit("org.freedesktop.DBus.ObjectManager.InterfacesAdded", path, map[string]map[string]dbus.Variant{"org.bluez.Adapter1": {}})
+}
+
+func (b *fakeBlueZ) removeAdapter(path dbus.ObjectPath) {
+	b.conn.Export(nil, path, "org.bluez.Adapter1")
+	b.conn.Export(nil, path, "org.freedesktop.DBus.Properties")
+	b.m.Lock()
+	delete(b.adapters, path)
+	b.m.Unlock()
+	b.emit("org.freedesktop.DBus.ObjectManager.InterfacesRemoved", path, []string{"org.bluez.Adapter1"})
+}
+
+func (b *fakeBlueZ) addDevice(path dbus.ObjectPath, address string, data []byte) {
+	b.addDeviceWithProperties(path, map[string]interface{}{
+		"Address":          address,
+		"ManufacturerData": map[uint16]dbus.Variant{0x0499: dbus.MakeVariant(data)},
+	})
+}
+
+func (b *fakeBlueZ) addDeviceWithProperties(path dbus.ObjectPath, properties map[string]interface{}) {
+	propMap := make(map[string]*prop.Prop)
+	variants := make(map[string]dbus.Variant)
+	for name, value := range properties {
+		propMap[name] = &prop.Prop{Value: value, Emit: prop.EmitTrue}
+		variants[name] = dbus.MakeVariant(value)
+	}
+	if _, err := prop.Export(b.conn, path, prop.Map{"org.bluez.Device1": propMap}); err != nil {
+		b.t.Fatalf("Failed to export device properties: %v", err)
+	}
+	b.emit("org.freedesktop.DBus.ObjectManager.InterfacesAdded", path, map[string]map[string]dbus.Variant{
+		"org.bluez.Device1": variants,
+	})
+}
+
+func (b *fakeBlueZ) emit(name string, values ...interface{}) {
+	if err := b.conn.Emit("/", name, values...); err != nil {
+		b.t.Fatalf("Failed to emit %s: %v", name, err)
+	}
+}
+
+func (b *fakeBlueZ) setProperty(path dbus.ObjectPath, name string, value interface{}) {
+	b.m.Lock()
+	props := b.adapters[path]
+	b.m.Unlock()
+	props.SetMust("org.bluez.Adapter1", name, value)
+}
+
+func (b *fakeBlueZ) waitForPowered(path dbus.ObjectPath) {
+	b.m.Lock()
+	props := b.adapters[path]
+	b.m.Unlock()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if props.GetMust("org.bluez.Adapter1", "Powered").(bool) {
+			return
+		}
+	}
+	b.t.Fatalf("Timed out waiting for adapter to be powered on")
+}
+
+func (b *fakeBlueZ) waitForDiscovery(calls int) {
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		b.m.Lock()
+		c := b.startDiscoveryCalls
+		b.m.Unlock()
+		if c == calls {
+			return
+		}
+	}
+	b.t.Fatalf("Timed out waiting for %d StartDiscovery calls", calls)
+}
+
+func (b *fakeBlueZ) waitForRemovedDevices(count int) {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		b.m.Lock()
+		removed := len(b.removedDevices)
+		b.m.Unlock()
+		if removed >= count {
+			return
+		}
+	}
+	b.t.Fatalf("Timed out waiting for %d removed devices", count)
+}
+
+func receive(t *testing.T, l Listener) Advertisement {
+	select {
+	case adv, ok := <-l.Advertisements():
+		if !ok {
+			t.Fatalf("Listener stopped: %v", l.Err())
+		}
+		return adv
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for advertisement")
+	}
+	panic("unreachable")
+}
+
+func TestAdvListenerRestarts(t *testing.T) {
+	address, stopBus := startBus(t)
+	bluez := newFakeBlueZ(t, address)
+	bluez.addAdapter("/org/bluez/hci0", true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l, err := NewAdvListener(ctx, "hci0", &Options{ScanMode: ScanModeDiscovery})
+	if err != nil {
+		t.Fatalf("Failed to create listener: %v", err)
+	}
+	bluez.waitForDiscovery(1)
+	bluez.addDevice("/org/bluez/hci0/dev_1", "CB:B8:33:4C:88:4F", []byte{0x05})
+	if adv := receive(t, l); adv.Address.String() != "cb:b8:33:4c:88:4f" || adv.Adapter != "hci0" {
+		t.Errorf("Unexpected advertisement: %v", adv)
+	}
+
+	// Adapter is unplugged and plugged again.
+	bluez.removeAdapter("/org/bluez/hci0")
+	bluez.addAdapter("/org/bluez/hci0", true)
+	bluez.waitForDiscovery(2)
+
+	// Bluetooth daemon is restarted.
+	bluez.conn.Close()
+	bluez = newFakeBlueZ(t, address)
+	bluez.addAdapter("/org/bluez/hci0", true)
+	bluez.waitForDiscovery(1)
+	bluez.addDevice("/org/bluez/hci0/dev_1", "CB:B8:33:4C:88:4F", []byte{0x05})
+	if adv := receive(t, l); adv.Address.String() != "cb:b8:33:4c:88:4f" {
+		t.Errorf("Unexpected advertisement: %v", adv)
+	}
+
+	// System bus is restarted.
+	stopBus()
+	address, _ = startBus(t)
+	bluez = newFakeBlueZ(t, address)
+	bluez.addAdapter("/org/bluez/hci0", true)
+	bluez.waitForDiscovery(1)
+
+	cancel()
+	for range l.Advertisements() {
+	}
+	if err := l.Err(); err != nil {
+		t.Errorf("Expected no error after cancel, got: %v", err)
+	}
+}
+
+func TestAdvListenerPowerOn(t *testing.T) {
+	address, _ := startBus(t)
+	bluez := newFakeBlueZ(t, address)
+	bluez.addAdapter("/org/bluez/hci0", false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l, err := NewAdvListener(ctx, "hci0", &Options{ScanMode: ScanModeDiscovery, PowerOn: true})
+	if err != nil {
+		t.Fatalf("Failed to create listener: %v", err)
+	}
+	bluez.waitForPowered("/org/bluez/hci0")
+	bluez.waitForDiscovery(1)
+
+	bluez.setProperty("/org/bluez/hci0", "Powered", false)
+	bluez.waitForPowered("/org/bluez/hci0")
+	bluez.waitForDiscovery(2)
+
+	cancel()
+	for range l.Advertisements() {
+	}
+	if err := l.Err(); err != nil {
+		t.Errorf("Expected no error after cancel, got: %v", err)
+	}
+}
+
+func TestAdvListenerRestartsDiscovery(t *testing.T) {
+	address, _ := startBus(t)
+	bluez := newFakeBlueZ(t, address)
+	bluez.addAdapter("/org/bluez/hci0", true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l, err := NewAdvListener(ctx, "hci0", &Options{ScanMode: ScanModeDiscovery})
+	if err != nil {
+		t.Fatalf("Failed to create listener: %v", err)
+	}
+	bluez.waitForDiscovery(1)
+	bluez.setProperty("/org/bluez/hci0", "Discovering", false)
+	bluez.waitForDiscovery(2)
+
+	cancel()
+	for range l.Advertisements() {
+	}
+}
+
+func TestAdvListenerRemovesStaleDevices(t *testing.T) {
+	address, _ := startBus(t)
+	bluez := newFakeBlueZ(t, address)
+	bluez.addAdapter("/org/bluez/hci0", true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l, err := NewAdvListener(ctx, "hci0", &Options{
+		ScanMode:           ScanModeDiscovery,
+		CacheTTL:           100 * time.Millisecond,
+		RemoveStaleDevices: true,
+		IsSensor: func(adv *Advertisement) bool {
+			return adv.Address.String() == "a4:c1:38:00:00:01"
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create listener: %v", err)
+	}
+	bluez.waitForDiscovery(1)
+
+	bluez.addDevice("/org/bluez/hci0/dev_A4_C1_38_00_00_01", "A4:C1:38:00:00:01", []byte{1})
+	bluez.addDevice("/org/bluez/hci0/dev_11_22_33_44_55_66", "11:22:33:44:55:66", []byte{2})
+	bluez.addDeviceWithProperties("/org/bluez/hci0/dev_66_55_44_33_22_11", map[string]interface{}{
+		"Address": "66:55:44:33:22:11",
+		"Paired":  true,
+	})
+	for i := 0; i < 2; i++ {
+		receive(t, l)
+	}
+
+	expected := []dbus.ObjectPath{"/org/bluez/hci0/dev_11_22_33_44_55_66"}
+	bluez.waitForRemovedDevices(1)
+	// Give the listener time to remove wrong devices, if it would.
+	time.Sleep(300 * time.Millisecond)
+	bluez.m.Lock()
+	removed := append([]dbus.ObjectPath(nil), bluez.removedDevices...)
+	bluez.m.Unlock()
+	if !reflect.DeepEqual(removed, expected) {
+		t.Errorf("Removed devices %v, expected %v", removed, expected)
+	}
+
+	cancel()
+	for range l.Advertisements() {
+	}
+}
+
+func TestAdvListenerRemovesEvictedDevices(t *testing.T) {
+	address, _ := startBus(t)
+	bluez := newFakeBlueZ(t, address)
+	bluez.addAdapter("/org/bluez/hci0", true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l, err := NewAdvListener(ctx, "hci0", &Options{
+		ScanMode:           ScanModeDiscovery,
+		CacheSize:          1,
+		CacheTTL:           time.Hour,
+		RemoveStaleDevices: true,
+		IsSensor: func(adv *Advertisement) bool {
+			return adv.Address.String() == "a4:c1:38:00:00:01"
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create listener: %v", err)
+	}
+	bluez.waitForDiscovery(1)
+
+	// Every device evicts the previous one from the cache.
+	bluez.addDevice("/org/bluez/hci0/dev_11_22_33_44_55_66", "11:22:33:44:55:66", []byte{1})
+	receive(t, l)
+	bluez.addDevice("/org/bluez/hci0/dev_A4_C1_38_00_00_01", "A4:C1:38:00:00:01", []byte{2})
+	receive(t, l)
+	bluez.waitForRemovedDevices(1)
+	bluez.addDevice("/org/bluez/hci0/dev_66_55_44_33_22_11", "66:55:44:33:22:11", []byte{3})
+	receive(t, l)
+
+	// Give the listener time to remove wrong devices, if it would.
+	time.Sleep(300 * time.Millisecond)
+	bluez.m.Lock()
+	removed := append([]dbus.ObjectPath(nil), bluez.removedDevices...)
+	bluez.m.Unlock()
+	if expected := []dbus.ObjectPath{"/org/bluez/hci0/dev_11_22_33_44_55_66"}; !reflect.DeepEqual(removed, expected) {
+		t.Errorf("Removed devices %v, expected %v", removed, expected)
+	}
+
+	cancel()
+	for range l.Advertisements() {
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blelistener
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// HCI packet indicators and events, see Bluetooth Core Specification,
+// Vol 4, Part E and Vol 4, Part A, Section 2.
+const (
+	hciCommandPkt = 0x01
+	hciEventPkt   = 0x04
+
+	hciEvtCmdComplete = 0x0e
+	hciEvtCmdStatus   = 0x0f
+	hciEvtLEMeta      = 0x3e
+
+	hciEvtLEAdvertisingReport = 0x02
+
+	hciOpLESetScanParameters = 0x200b
+	hciOpLESetScanEnable     = 0x200c
+)
+
+// Advertising data types, see Bluetooth Assigned Numbers, Section 2.3.
+const (
+	adTypeShortName       = 0x08
+	adTypeCompleteName    = 0x09
+	adTypeTxPower         = 0x0a
+	adTypeServiceData16   = 0x16
+	adTypeServiceData32   = 0x20
+	adTypeServiceData128  = 0x21
+	adTypeManufacturerSpc = 0xff
+)
+
+// rssiNotAvailable is the RSSI value in the advertising report when it can't
+// be determined.
+const rssiNotAvailable = 127
+
+// hciCommandPacket returns HCI command packet with the given opcode and
+// parameters.
+func hciCommandPacket(opcode uint16, params ...byte) []byte {
+	pkt := []byte{hciCommandPkt, byte(opcode), byte(opcode >> 8), byte(len(params))}
+	return append(pkt, params...)
+}
+
+// bdaddrToHardwareAddr converts Bluetooth device address from the little
+// endian order used in HCI packets.
+func bdaddrToHardwareAddr(bdaddr []byte) net.HardwareAddr {
+	addr := make(net.HardwareAddr, len(bdaddr))
+	for i, b := range bdaddr {
+		addr[len(bdaddr)-1-i] = b
+	}
+	return addr
+}
+
+// uuidToString converts little endian encoded UUID of 2, 4 or 16 bytes to the
+// string format used for ServiceData keys.
+func uuidToString(uuid []byte) string {
+	switch len(uuid) {
+	case 2:
+		return ServiceDataUUID(binary.LittleEndian.Uint16(uuid))
+	case 4:
+		return fmt.Sprintf("%08x-0000-1000-8000-00805f9b34fb", binary.LittleEndian.Uint32(uuid))
+	}
+	u := bdaddrToHardwareAddr(uuid)
+	return fmt.Sprintf("%x-%x-%x-%x-%x", []byte(u[0:4]), []byte(u[4:6]), []byte(u[6:8]), []byte(u[8:10]), []byte(u[10:16]))
+}
+
+// parseAdvertisingData parses the advertising data structures into adv.
+func parseAdvertisingData(data []byte, adv *Advertisement) error {
+	for len(data) > 0 {
+		length := int(data[0])
+		if length == 0 {
+			// Zero length is valid and means early end of data.
+			return nil
+		}
+		if len(data) < length+1 {
+			return fmt.Errorf("advertising data structure longer then data: %d > %d", length, len(data)-1)
+		}
+		adType, value := data[1], data[2:length+1]
+		data = data[length+1:]
+
+		uuidLen := 0
+		switch adType {
+		case adTypeShortName:
+			if adv.Name == "" {
+				adv.Name = string(value)
+			}
+		case adTypeCompleteName:
+			adv.Name = string(value)
+		case adTypeTxPower:
+			if len(value) != 1 {
+				return fmt.Errorf("tx power has wrong length %d", len(value))
+			}
+			txPower := int16(int8(value[0]))
+			adv.TxPower = &txPower
+		case adTypeManufacturerSpc:
+			if len(value) < 2 {
+				return fmt.Errorf("manufacturer data too short")
+			}
+			if adv.ManufacturerData == nil {
+				adv.ManufacturerData = make(ManufacturerData)
+			}
+			adv.ManufacturerData[binary.LittleEndian.Uint16(value)] = append([]byte(nil), value[2:]...)
+		case adTypeServiceData16:
+			uuidLen = 2
+		case adTypeServiceData32:
+			uuidLen = 4
+		case adTypeServiceData128:
+			uuidLen = 16
+		}
+		if uuidLen > 0 {
+			if len(value) < uuidLen {
+				return fmt.Errorf("service data too short for %d bytes uuid", uuidLen)
+			}
+			if adv.ServiceData == nil {
+				adv.ServiceData = make(ServiceData)
+			}
+			adv.ServiceData[uuidToString(value[:uuidLen])] = append([]byte(nil), value[uuidLen:]...)
+		}
+	}
+	return nil
+}
+
+// parseLEAdvertisingReport parses parameters of the HCI LE Advertising Report
+// event, after the subevent code, into advertisements.
+func parseLEAdvertisingReport(params []byte) ([]Advertisement, error) {
+	if len(params) < 1 {
+		return nil, fmt.Errorf("advertising report is empty")
+	}
+	numReports := int(params[0])
+	params = params[1:]
+	var advs []Advertisement
+	// Reports are parsed sequentially like in Linux kernel and BlueZ, in
+	// practice controllers send only one report per event.
+	for i := 0; i < numReports; i++ {
+		if len(params) < 9 {
+			return nil, fmt.Errorf("advertising report %d too short", i)
+		}
+		addressType, bdaddr, dataLen := params[1], params[2:8], int(params[8])
+		if len(params) < 9+dataLen+1 {
+			return nil, fmt.Errorf("advertising report %d data too short", i)
+		}
+		adv := Advertisement{
+			Address:     bdaddrToHardwareAddr(bdaddr),
+			AddressType: "public",
+		}
+		if addressType&0x01 != 0 {
+			adv.AddressType = "random"
+		}
+		if err := parseAdvertisingData(params[9:9+dataLen], &adv); err != nil {
+			return nil, fmt.Errorf("failed to parse advertising data of %s: %v", adv.Address, err)
+		}
+		if rssi := int8(params[9+dataLen]); rssi != rssiNotAvailable {
+			r := int16(rssi)
+			adv.RSSI = &r
+		}
+		advs = append(advs, adv)
+		params = params[9+dataLen+1:]
+	}
+	return advs, nil
+}
@@ -0,0 +1,256 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package blelistener
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// HCI_FILTER socket option, see include/net/bluetooth/hci_sock.h in Linux.
+	hciFilterOpt = 2
+
+	// Maximum size of HCI event packet: indicator, header and parameters.
+	hciMaxEventSize = 1 + 2 + 255
+
+	hciCommandTimeout = 2 * time.Second
+
+	// Timeout of a single read from the socket, it bounds how long it takes
+	// for the read loop to notice that the listener was closed.
+	hciReadTimeout = 500 * time.Millisecond
+)
+
+// HCIListener listens for BLE advertisements by passively scanning directly
+// on the raw HCI socket of the adapter, without the BlueZ daemon, and returns
+// them via Advertisements() channel. It implements Listener.
+//
+// The adapter must be up (`hciconfig hci0 up`), and the process requires the
+// CAP_NET_RAW and CAP_NET_ADMIN capabilities.
+type HCIListener struct {
+	adapterName string
+	fd          int
+	m           sync.Mutex // Guards err
+	queue       *advQueue
+	done        chan struct{}
+	closeOnce   sync.Once
+	err         error
+}
+
+var _ Listener = (*HCIListener)(nil)
+
+// Advertisements returns a channel that HCIListener publishes advertisements on.
+func (l *HCIListener) Advertisements() <-chan Advertisement {
+	return l.queue.results
+}
+
+// Dropped implements Listener.
+func (l *HCIListener) Dropped() uint64 {
+	return l.queue.droppedCount()
+}
+
+// Err implements Listener.
+func (l *HCIListener) Err() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.err
+}
+
+// Close implements Listener. The socket itself is closed by the read loop
+// after it notices that the listener was closed.
+func (l *HCIListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+	})
+	return nil
+}
+
+func (l *HCIListener) setError(err error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if l.err == nil {
+		l.err = err
+	}
+}
+
+// isTimeout returns whether the error returned from read is caused by the
+// socket receive timeout.
+func isTimeout(err error) bool {
+	return err == unix.EAGAIN || err == unix.EWOULDBLOCK
+}
+
+// setFilter configures socket to receive only events used by the listener.
+func (l *HCIListener) setFilter() error {
+	var typeMask uint32 = 1 << hciEventPkt
+	var eventMask [2]uint32
+	for _, evt := range []uint{hciEvtCmdComplete, hciEvtCmdStatus, hciEvtLEMeta} {
+		eventMask[evt>>5] |= 1 << (evt & 31)
+	}
+	filter := make([]byte, 16)
+	binary.LittleEndian.PutUint32(filter[0:], typeMask)
+	binary.LittleEndian.PutUint32(filter[4:], eventMask[0])
+	binary.LittleEndian.PutUint32(filter[8:], eventMask[1])
+	return unix.SetsockoptString(l.fd, unix.SOL_HCI, hciFilterOpt, string(filter))
+}
+
+// sendCommand sends HCI command and waits for its completion. It must be
+// called before the readLoop is started.
+func (l *HCIListener) sendCommand(opcode uint16, params ...byte) error {
+	if _, err := unix.Write(l.fd, hciCommandPacket(opcode, params...)); err != nil {
+		return fmt.Errorf("failed to write command: %v", err)
+	}
+	deadline := time.Now().Add(hciCommandTimeout)
+	buf := make([]byte, hciMaxEventSize)
+	for time.Now().Before(deadline) {
+		n, err := unix.Read(l.fd, buf)
+		if err == unix.EINTR || isTimeout(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to read command response: %v", err)
+		}
+		if n < 3 || buf[0] != hciEventPkt {
+			continue
+		}
+		params := buf[3:n]
+		switch {
+		case buf[1] == hciEvtCmdComplete && len(params) >= 4 && binary.LittleEndian.Uint16(params[1:]) == opcode:
+			if status := params[3]; status != 0 {
+				return fmt.Errorf("command failed with status 0x%02x", status)
+			}
+			return nil
+		case buf[1] == hciEvtCmdStatus && len(params) >= 4 && binary.LittleEndian.Uint16(params[2:]) == opcode:
+			if status := params[0]; status != 0 {
+				return fmt.Errorf("command failed with status 0x%02x", status)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("timed out waiting for command response")
+}
+
+// startScan enables passive LE scanning without duplicate filtering, so that
+// every advertisement is reported.
+func (l *HCIListener) startScan() error {
+	// Scanning might be already enabled, and then parameters can't be changed.
+	if err := l.sendCommand(hciOpLESetScanEnable, 0x00, 0x00); err != nil {
+		log.Printf("Failed to disable LE scan on %s, ignoring: %v", l.adapterName, err)
+	}
+	// Passive scan, 10ms interval and window, public own address, accept all.
+	if err := l.sendCommand(hciOpLESetScanParameters, 0x00, 0x10, 0x00, 0x10, 0x00, 0x00, 0x00); err != nil {
+		return fmt.Errorf("failed to set LE scan parameters: %v", err)
+	}
+	if err := l.sendCommand(hciOpLESetScanEnable, 0x01, 0x00); err != nil {
+		return fmt.Errorf("failed to enable LE scan: %v", err)
+	}
+	return nil
+}
+
+func (l *HCIListener) readLoop() {
+	defer l.queue.close()
+	defer func() {
+		if err := unix.Close(l.fd); err != nil {
+			log.Printf("Closing HCI socket failed: %v", err)
+		}
+	}()
+
+	buf := make([]byte, hciMaxEventSize)
+	for {
+		select {
+		case <-l.done:
+			// Best effort, nothing else can be done when it fails.
+			unix.Write(l.fd, hciCommandPacket(hciOpLESetScanEnable, 0x00, 0x00))
+			return
+		default:
+		}
+		n, err := unix.Read(l.fd, buf)
+		if err == unix.EINTR || isTimeout(err) {
+			continue
+		} else if err != nil {
+			l.setError(fmt.Errorf("failed to read from HCI socket: %v", err))
+			return
+		}
+		if n < 4 || buf[0] != hciEventPkt || buf[1] != hciEvtLEMeta || buf[3] != hciEvtLEAdvertisingReport {
+			continue
+		}
+		receivedAt := time.Now()
+		advs, err := parseLEAdvertisingReport(buf[4:n])
+		if err != nil {
+			log.Printf("Failed to parse LE Advertising Report: %v", err)
+			continue
+		}
+		for _, adv := range advs {
+			if len(adv.ManufacturerData) == 0 && len(adv.ServiceData) == 0 {
+				continue
+			}
+			adv.Adapter = l.adapterName
+			adv.ReceivedAt = receivedAt
+			l.queue.push(adv)
+		}
+	}
+}
+
+// NewHCIListener creates new HCIListener and starts listening. The listener
+// is closed when ctx is cancelled.
+func NewHCIListener(ctx context.Context, adapterName string) (*HCIListener, error) {
+	var devID uint16
+	if _, err := fmt.Sscanf(adapterName, "hci%d", &devID); err != nil {
+		return nil, fmt.Errorf("adapter name '%s' is not in the hciN format", adapterName)
+	}
+	fd, err := unix.Socket(unix.AF_BLUETOOTH, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.BTPROTO_HCI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open HCI socket: %v", err)
+	}
+	l := &HCIListener{
+		adapterName: adapterName,
+		fd:          fd,
+		queue:       newAdvQueue(DefaultQueueSize),
+		done:        make(chan struct{}),
+	}
+	if err := unix.Bind(fd, &unix.SockaddrHCI{Dev: devID, Channel: unix.HCI_CHANNEL_RAW}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to bind HCI socket to %s: %v", adapterName, err)
+	}
+	if err := l.setFilter(); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to set HCI socket filter: %v", err)
+	}
+	readTimeout := unix.NsecToTimeval(hciReadTimeout.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &readTimeout); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to set HCI socket read timeout: %v", err)
+	}
+	if err := l.startScan(); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	go l.readLoop()
+	go l.queue.deliver(l.done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			l.Close()
+		case <-l.done:
+		}
+	}()
+	return l, nil
+}
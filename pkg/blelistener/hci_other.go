@@ -0,0 +1,55 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+
+package blelistener
+
+import (
+	"context"
+	"fmt"
+)
+
+// HCIListener listens for BLE advertisements on the raw HCI socket. It's only
+// supported on Linux.
+type HCIListener struct {
+	results chan Advertisement
+}
+
+var _ Listener = (*HCIListener)(nil)
+
+// Advertisements implements Listener.
+func (l *HCIListener) Advertisements() <-chan Advertisement {
+	return l.results
+}
+
+// Err implements Listener.
+func (l *HCIListener) Err() error {
+	return nil
+}
+
+// Dropped implements Listener.
+func (l *HCIListener) Dropped() uint64 {
+	return 0
+}
+
+// Close implements Listener.
+func (l *HCIListener) Close() error {
+	return nil
+}
+
+// NewHCIListener always fails as raw HCI sockets are not supported.
+func NewHCIListener(ctx context.Context, adapterName string) (*HCIListener, error) {
+	return nil, fmt.Errorf("raw HCI socket backend is supported only on Linux")
+}
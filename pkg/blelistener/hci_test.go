@@ -0,0 +1,85 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blelistener
+
+import (
+	"encoding/hex"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func toB(s string) []byte {
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		panic("Got invalid hex string")
+	}
+	return decoded
+}
+
+func TestParseLEAdvertisingReport(t *testing.T) {
+	data := toB("020106" + // flags
+		"1BFF9904" + "0512FC5394C37C0004FFFC040CAC364200CDCBB8334C884F" + // manufacturer data
+		"06161A18010203" + // 16-bit service data
+		"0720D2FC0000AABB" + // 32-bit service data
+		"12219ECADC240EE5A9E093F3A3B5010040" + "6EEE" + // 128-bit service data
+		"0508" + "52757576" + // short name
+		"020AFC") // tx power
+	report := append([]byte{0x01, 0x03, 0x01, 0x4f, 0x88, 0x4c, 0x33, 0xb8, 0xcb, byte(len(data))}, data...)
+	report = append(report, 0xc4)
+
+	advs, err := parseLEAdvertisingReport(report)
+	if err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+	expected := []Advertisement{{
+		Address:     net.HardwareAddr{0xcb, 0xb8, 0x33, 0x4c, 0x88, 0x4f},
+		AddressType: "random",
+		Name:        "Ruuv",
+		ManufacturerData: ManufacturerData{
+			0x0499: toB("0512FC5394C37C0004FFFC040CAC364200CDCBB8334C884F"),
+		},
+		ServiceData: ServiceData{
+			"0000181a-0000-1000-8000-00805f9b34fb": {0x01, 0x02, 0x03},
+			"0000fcd2-0000-1000-8000-00805f9b34fb": {0xaa, 0xbb},
+			"6e400001-b5a3-f393-e0a9-e50e24dcca9e": {0xee},
+		},
+		RSSI:    int16Ptr(-60),
+		TxPower: int16Ptr(-4),
+	}}
+	if diff := cmp.Diff(advs, expected); diff != "" {
+		t.Errorf("unexpected difference:\n%v", diff)
+	}
+}
+
+func TestParseLEAdvertisingReportInvalid(t *testing.T) {
+	cases := []struct {
+		name   string
+		report string
+	}{
+		{"empty", ""},
+		{"too short", "0103014F884C33B8"},
+		{"data longer than report", "0103014F884C33B8CB05020106"},
+		{"structure longer than data", "0103014F884C33B8CB03050106C4"},
+		{"short manufacturer data", "0103014F884C33B8CB0302FF99C4"},
+		{"short service data", "0103014F884C33B8CB0302161AC4"},
+	}
+	for _, c := range cases {
+		if _, err := parseLEAdvertisingReport(toB(c.report)); err == nil {
+			t.Errorf("%s: expected error, got success", c.name)
+		}
+	}
+}
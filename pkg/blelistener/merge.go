@@ -0,0 +1,94 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blelistener
+
+import (
+	"sync"
+)
+
+// mergedListener publishes advertisements from multiple listeners on a single
+// channel.
+type mergedListener struct {
+	listeners []Listener
+	results   chan Advertisement
+	m         sync.Mutex // Guards err
+	err       error
+}
+
+// Merge returns a Listener that publishes advertisements received by all the
+// listeners. Advertisement.Adapter tells which adapter received each of them.
+// When any of the listeners fails, all are closed, and Err returns the first
+// error.
+func Merge(listeners ...Listener) Listener {
+	l := &mergedListener{
+		listeners: listeners,
+		results:   make(chan Advertisement, 10),
+	}
+	var wg sync.WaitGroup
+	for _, listener := range listeners {
+		wg.Add(1)
+		go func(listener Listener) {
+			defer wg.Done()
+			for adv := range listener.Advertisements() {
+				l.results <- adv
+			}
+			if err := listener.Err(); err != nil {
+				l.m.Lock()
+				if l.err == nil {
+					l.err = err
+				}
+				l.m.Unlock()
+				l.Close()
+			}
+		}(listener)
+	}
+	go func() {
+		wg.Wait()
+		close(l.results)
+	}()
+	return l
+}
+
+// Advertisements implements Listener.
+func (l *mergedListener) Advertisements() <-chan Advertisement {
+	return l.results
+}
+
+// Err implements Listener.
+func (l *mergedListener) Err() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.err
+}
+
+// Dropped implements Listener.
+func (l *mergedListener) Dropped() uint64 {
+	var dropped uint64
+	for _, listener := range l.listeners {
+		dropped += listener.Dropped()
+	}
+	return dropped
+}
+
+// Close implements Listener.
+func (l *mergedListener) Close() error {
+	var firstErr error
+	for _, listener := range l.listeners {
+		if err := listener.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blelistener
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+)
+
+type fakeListener struct {
+	advs      chan Advertisement
+	err       error
+	closeOnce sync.Once
+}
+
+func newFakeListener() *fakeListener {
+	return &fakeListener{advs: make(chan Advertisement, 10)}
+}
+
+func (l *fakeListener) Advertisements() <-chan Advertisement { return l.advs }
+func (l *fakeListener) Err() error                           { return l.err }
+func (l *fakeListener) Dropped() uint64                      { return 0 }
+func (l *fakeListener) Close() error {
+	l.closeOnce.Do(func() { close(l.advs) })
+	return nil
+}
+
+func TestMerge(t *testing.T) {
+	l1, l2 := newFakeListener(), newFakeListener()
+	merged := Merge(l1, l2)
+	l1.advs <- Advertisement{Adapter: "hci0"}
+	l2.advs <- Advertisement{Adapter: "hci1"}
+	l1.advs <- Advertisement{Adapter: "hci0"}
+
+	var adapters []string
+	for i := 0; i < 3; i++ {
+		adapters = append(adapters, (<-merged.Advertisements()).Adapter)
+	}
+	sort.Strings(adapters)
+	if len(adapters) != 3 || adapters[0] != "hci0" || adapters[1] != "hci0" || adapters[2] != "hci1" {
+		t.Errorf("unexpected adapters of received advertisements: %v", adapters)
+	}
+
+	merged.Close()
+	if _, ok := <-merged.Advertisements(); ok {
+		t.Errorf("Expected closed channel after Close")
+	}
+	if err := merged.Err(); err != nil {
+		t.Errorf("Expected no error after Close, got %v", err)
+	}
+}
+
+func TestMergeError(t *testing.T) {
+	l1, l2 := newFakeListener(), newFakeListener()
+	merged := Merge(l1, l2)
+
+	l1.err = errors.New("adapter removed")
+	l1.Close()
+	for range merged.Advertisements() {
+	}
+	if merged.Err() != l1.err {
+		t.Errorf("Got error %v, expected %v", merged.Err(), l1.err)
+	}
+}
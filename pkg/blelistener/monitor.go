@@ -0,0 +1,197 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blelistener
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+// ScanMode selects how AdvListener makes BlueZ scan for advertisements.
+type ScanMode int
+
+const (
+	// ScanModeAuto uses ScanModeMonitor if BlueZ supports it, and
+	// ScanModeDiscovery otherwise.
+	ScanModeAuto ScanMode = iota
+
+	// ScanModeDiscovery uses org.bluez.Adapter1.StartDiscovery, it's active
+	// scanning that sends scan requests to all devices around.
+	ScanModeDiscovery
+
+	// ScanModeMonitor uses org.bluez.AdvertisementMonitor1 with the passive
+	// scanning for advertisements matching the MonitorPatterns. Depending on
+	// the version, BlueZ might need to be started with --experimental flag.
+	ScanModeMonitor
+)
+
+// MonitorPattern is a pattern for the or_patterns AdvertisementMonitor1. It
+// matches advertisements that contain advertising data structure of ADType
+// with Content at StartPosition.
+type MonitorPattern struct {
+	StartPosition uint8
+	ADType        uint8
+	Content       []byte
+}
+
+// ManufacturerDataPattern returns pattern matching manufacturer data of the
+// given manufacturer.
+func ManufacturerDataPattern(manufacturerID uint16) MonitorPattern {
+	return MonitorPattern{
+		ADType:  adTypeManufacturerSpc,
+		Content: []byte{byte(manufacturerID), byte(manufacturerID >> 8)},
+	}
+}
+
+// ServiceDataPattern returns pattern matching service data of the service with
+// uuid in the ServiceData keys format.
+func ServiceDataPattern(uuid string) (MonitorPattern, error) {
+	raw, err := hex.DecodeString(strings.ReplaceAll(uuid, "-", ""))
+	if err != nil || len(raw) != 16 {
+		return MonitorPattern{}, fmt.Errorf("'%s' is not a valid 128-bit UUID", uuid)
+	}
+	if strings.HasSuffix(uuid, "-0000-1000-8000-00805f9b34fb") && strings.HasPrefix(uuid, "0000") {
+		return MonitorPattern{ADType: adTypeServiceData16, Content: []byte{raw[3], raw[2]}}, nil
+	}
+	return MonitorPattern{ADType: adTypeServiceData128, Content: reverseBytes(raw)}, nil
+}
+
+// reverseBytes returns copy of b with bytes in reverse order, e.g. to convert
+// UUID to the little endian order used in advertising data.
+func reverseBytes(b []byte) []byte {
+	res := make([]byte, len(b))
+	for i, v := range b {
+		res[len(b)-1-i] = v
+	}
+	return res
+}
+
+// LocalNamePattern returns pattern matching complete local name starting with
+// the prefix.
+func LocalNamePattern(prefix string) MonitorPattern {
+	return MonitorPattern{ADType: adTypeCompleteName, Content: []byte(prefix)}
+}
+
+// advMonitor implements org.bluez.AdvertisementMonitor1 D-Bus interface, see
+// https://git.kernel.org/pub/scm/bluetooth/bluez.git/tree/doc/advertisement-monitor-api.txt
+type advMonitor struct {
+	l *AdvListener
+}
+
+// Release is called by BlueZ when the monitor is no longer used, e.g. when
+// the adapter is removed. Restarting scanning is handled in AdvListener.serve.
+func (m *advMonitor) Release() *dbus.Error {
+	log.Printf("Advertisement monitor on %s released", m.l.adapterName)
+	return nil
+}
+
+// Activate is called by BlueZ when the monitor starts monitoring.
+func (m *advMonitor) Activate() *dbus.Error {
+	log.Printf("Advertisement monitor on %s activated", m.l.adapterName)
+	return nil
+}
+
+// DeviceFound is called by BlueZ when device matching the monitor is found.
+// Advertisements are received via PropertiesChanged signal, as in discovery.
+func (m *advMonitor) DeviceFound(device dbus.ObjectPath) *dbus.Error {
+	return nil
+}
+
+// DeviceLost is called by BlueZ when device is no longer matched.
+func (m *advMonitor) DeviceLost(device dbus.ObjectPath) *dbus.Error {
+	return nil
+}
+
+// monitorApp implements the org.freedesktop.DBus.ObjectManager on the root of
+// the application registered with the AdvertisementMonitorManager1.
+type monitorApp struct {
+	monitorPath dbus.ObjectPath
+	props       map[string]dbus.Variant
+}
+
+func (a *monitorApp) GetManagedObjects() (map[dbus.ObjectPath]map[string]map[string]dbus.Variant, *dbus.Error) {
+	return map[dbus.ObjectPath]map[string]map[string]dbus.Variant{
+		a.monitorPath: {"org.bluez.AdvertisementMonitor1": a.props},
+	}, nil
+}
+
+// monitorProperties returns properties of the AdvertisementMonitor1 object.
+func monitorProperties(patterns []MonitorPattern) map[string]dbus.Variant {
+	type pattern struct {
+		StartPosition uint8
+		ADType        uint8
+		Content       []byte
+	}
+	var ps []pattern
+	for _, p := range patterns {
+		ps = append(ps, pattern(p))
+	}
+	return map[string]dbus.Variant{
+		"Type":     dbus.MakeVariant("or_patterns"),
+		"Patterns": dbus.MakeVariant(ps),
+	}
+}
+
+// monitorSupported returns whatever the adapter supports or_patterns
+// advertisement monitors.
+func (l *AdvListener) monitorSupported() bool {
+	var types []string
+	if err := l.adapter.StoreProperty("org.bluez.AdvertisementMonitorManager1.SupportedMonitorTypes", &types); err != nil {
+		return false
+	}
+	for _, t := range types {
+		if t == "or_patterns" {
+			return true
+		}
+	}
+	return false
+}
+
+// registerMonitor exports the advertisement monitor application and registers
+// it with BlueZ.
+func (l *AdvListener) registerMonitor(patterns []MonitorPattern) error {
+	if len(patterns) == 0 {
+		return fmt.Errorf("advertisement monitor requires at least one pattern")
+	}
+	appPath := dbus.ObjectPath("/org/p2004a/gbcsdpd/" + l.adapterName)
+	monitorPath := appPath + "/monitor0"
+	props := monitorProperties(patterns)
+
+	if err := l.conn.Export(&advMonitor{l}, monitorPath, "org.bluez.AdvertisementMonitor1"); err != nil {
+		return fmt.Errorf("failed to export advertisement monitor: %v", err)
+	}
+	propsMap := prop.Map{"org.bluez.AdvertisementMonitor1": {}}
+	for name, value := range props {
+		propsMap["org.bluez.AdvertisementMonitor1"][name] = &prop.Prop{Value: value.Value(), Emit: prop.EmitFalse}
+	}
+	if _, err := prop.Export(l.conn, monitorPath, propsMap); err != nil {
+		return fmt.Errorf("failed to export advertisement monitor properties: %v", err)
+	}
+	if err := l.conn.Export(&monitorApp{monitorPath, props}, appPath, "org.freedesktop.DBus.ObjectManager"); err != nil {
+		return fmt.Errorf("failed to export advertisement monitor application: %v", err)
+	}
+	if err := l.adapter.Call("org.bluez.AdvertisementMonitorManager1.RegisterMonitor", 0, appPath).Err; err != nil {
+		return fmt.Errorf("failed to register advertisement monitor: %v", err)
+	}
+	l.m.Lock()
+	l.monitorApp = appPath
+	l.m.Unlock()
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blelistener
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMonitorPatterns(t *testing.T) {
+	cases := []struct {
+		name     string
+		pattern  func() (MonitorPattern, error)
+		expected MonitorPattern
+	}{
+		{"manufacturer", func() (MonitorPattern, error) { return ManufacturerDataPattern(0x0499), nil },
+			MonitorPattern{ADType: 0xff, Content: []byte{0x99, 0x04}}},
+		{"16-bit service", func() (MonitorPattern, error) { return ServiceDataPattern(ServiceDataUUID(0xfcd2)) },
+			MonitorPattern{ADType: 0x16, Content: []byte{0xd2, 0xfc}}},
+		{"128-bit service", func() (MonitorPattern, error) {
+			return ServiceDataPattern("6e400001-b5a3-f393-e0a9-e50e24dcca9e")
+		}, MonitorPattern{ADType: 0x21, Content: toB("9ECADC240EE5A9E093F3A3B50100406E")}},
+		{"name", func() (MonitorPattern, error) { return LocalNamePattern("ATC_"), nil },
+			MonitorPattern{ADType: 0x09, Content: []byte("ATC_")}},
+	}
+	for _, c := range cases {
+		p, err := c.pattern()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+		if diff := cmp.Diff(p, c.expected); diff != "" {
+			t.Errorf("%s: unexpected difference:\n%v", c.name, diff)
+		}
+	}
+
+	if _, err := ServiceDataPattern("181a"); err == nil {
+		t.Errorf("Expected error for invalid UUID, got success")
+	}
+}
+
+func TestMonitorProperties(t *testing.T) {
+	props := monitorProperties([]MonitorPattern{ManufacturerDataPattern(0x0499)})
+	if props["Type"].Value() != "or_patterns" {
+		t.Errorf("unexpected monitor type: %v", props["Type"])
+	}
+	if sig := props["Patterns"].Signature(); sig != dbus.ParseSignatureMust("a(yyay)") {
+		t.Errorf("unexpected patterns signature: %v", sig)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blelistener
+
+import (
+	"bytes"
+	"container/list"
+	"sync"
+)
+
+// DefaultQueueSize is the default value of Options.QueueSize.
+const DefaultQueueSize = 256
+
+// advQueue decouples listeners from consumers of advertisements, so a slow
+// consumer never blocks receiving of advertisements. It holds at most one
+// advertisement per device: a newer one replaces the queued one in place, so
+// devices advertising often don't lose their position, and when the queue is
+// full, the oldest queued advertisement is dropped. Replaced advertisements
+// are counted as dropped only when their payload differs, as otherwise no
+// data is lost.
+type advQueue struct {
+	results chan Advertisement
+	ready   chan struct{} // Signaled when advertisement is pushed or queue closed
+	m       sync.Mutex    // Guards everything below
+	maxSize int
+	queued  map[string]*list.Element
+	order   *list.List // Of Advertisement, the oldest first
+	closed  bool
+	dropped uint64
+}
+
+func newAdvQueue(maxSize int) *advQueue {
+	return &advQueue{
+		results: make(chan Advertisement),
+		ready:   make(chan struct{}, 1),
+		maxSize: maxSize,
+		queued:  make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// push adds advertisement to the queue, it never blocks.
+func (q *advQueue) push(adv Advertisement) {
+	key := adv.Address.String()
+	q.m.Lock()
+	if e, ok := q.queued[key]; ok {
+		if !samePayload(e.Value.(Advertisement), adv) {
+			q.dropped++
+		}
+		e.Value = adv
+		q.m.Unlock()
+		return
+	}
+	if q.order.Len() >= q.maxSize {
+		oldest := q.order.Front()
+		delete(q.queued, q.order.Remove(oldest).(Advertisement).Address.String())
+		q.dropped++
+	}
+	q.queued[key] = q.order.PushBack(adv)
+	q.m.Unlock()
+	q.signal()
+}
+
+// samePayload returns whatever advertisements carry the same manufacturer and
+// service data.
+func samePayload(a, b Advertisement) bool {
+	if len(a.ManufacturerData) != len(b.ManufacturerData) || len(a.ServiceData) != len(b.ServiceData) {
+		return false
+	}
+	for id, data := range a.ManufacturerData {
+		if other, ok := b.ManufacturerData[id]; !ok || !bytes.Equal(data, other) {
+			return false
+		}
+	}
+	for uuid, data := range a.ServiceData {
+		if other, ok := b.ServiceData[uuid]; !ok || !bytes.Equal(data, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// close marks that nothing more will be pushed, results channel is closed
+// after all queued advertisements are delivered.
+func (q *advQueue) close() {
+	q.m.Lock()
+	q.closed = true
+	q.m.Unlock()
+	q.signal()
+}
+
+func (q *advQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the oldest queued advertisement, if any, and whatever the queue
+// is closed.
+func (q *advQueue) pop() (Advertisement, bool, bool) {
+	q.m.Lock()
+	defer q.m.Unlock()
+	oldest := q.order.Front()
+	if oldest == nil {
+		return Advertisement{}, false, q.closed
+	}
+	adv := q.order.Remove(oldest).(Advertisement)
+	delete(q.queued, adv.Address.String())
+	return adv, true, q.closed
+}
+
+// droppedCount returns the number of dropped advertisements.
+func (q *advQueue) droppedCount() uint64 {
+	q.m.Lock()
+	defer q.m.Unlock()
+	return q.dropped
+}
+
+// deliver sends queued advertisements on the results channel until the queue
+// is closed and empty, or done is closed. Then, it closes the results channel.
+func (q *advQueue) deliver(done <-chan struct{}) {
+	defer close(q.results)
+	for {
+		adv, ok, closed := q.pop()
+		if !ok {
+			if closed {
+				return
+			}
+			select {
+			case <-q.ready:
+			case <-done:
+				return
+			}
+			continue
+		}
+		select {
+		case q.results <- adv:
+		case <-done:
+			return
+		}
+	}
+}
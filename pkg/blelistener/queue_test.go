@@ -0,0 +1,92 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blelistener
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func queueAdv(t *testing.T, address string, name string, data ...byte) Advertisement {
+	addr, err := net.ParseMAC(address)
+	if err != nil {
+		t.Fatalf("Failed to parse MAC: %v", err)
+	}
+	return Advertisement{Address: addr, Name: name, ManufacturerData: ManufacturerData{0x0499: data}}
+}
+
+func receiveAll(q *advQueue) []string {
+	done := make(chan struct{})
+	defer close(done)
+	go q.deliver(done)
+	var names []string
+	for adv := range q.results {
+		names = append(names, adv.Name)
+	}
+	return names
+}
+
+func TestAdvQueue(t *testing.T) {
+	q := newAdvQueue(3)
+	q.push(queueAdv(t, "00:00:00:00:00:01", "1", 0x01))
+	q.push(queueAdv(t, "00:00:00:00:00:02", "2", 0x01))
+	// Replaces the queued advertisement of the same device in place, with
+	// the same payload nothing is lost.
+	q.push(queueAdv(t, "00:00:00:00:00:01", "1b", 0x01))
+	if dropped := q.droppedCount(); dropped != 0 {
+		t.Errorf("Dropped %d advertisements after replacement with the same payload, expected 0", dropped)
+	}
+	// Replacement with a different payload loses a measurement.
+	q.push(queueAdv(t, "00:00:00:00:00:01", "1c", 0x02))
+	if dropped := q.droppedCount(); dropped != 1 {
+		t.Errorf("Dropped %d advertisements after replacement with new payload, expected 1", dropped)
+	}
+	q.push(queueAdv(t, "00:00:00:00:00:03", "3"))
+	// Drops the oldest queued advertisement.
+	q.push(queueAdv(t, "00:00:00:00:00:04", "4"))
+	q.close()
+
+	if dropped := q.droppedCount(); dropped != 2 {
+		t.Errorf("Dropped %d advertisements, expected 2", dropped)
+	}
+	if names := receiveAll(q); !reflect.DeepEqual(names, []string{"2", "3", "4"}) {
+		t.Errorf("Received %v, expected [2 3 4]", names)
+	}
+}
+
+func TestAdvQueueReplaceKeepsPosition(t *testing.T) {
+	q := newAdvQueue(3)
+	q.push(queueAdv(t, "00:00:00:00:00:01", "1"))
+	q.push(queueAdv(t, "00:00:00:00:00:02", "2"))
+	q.push(queueAdv(t, "00:00:00:00:00:01", "1b"))
+	q.push(queueAdv(t, "00:00:00:00:00:01", "1c"))
+	q.close()
+
+	if names := receiveAll(q); !reflect.DeepEqual(names, []string{"1c", "2"}) {
+		t.Errorf("Received %v, expected [1c 2]", names)
+	}
+}
+
+func TestAdvQueueDeliverStopsOnDone(t *testing.T) {
+	q := newAdvQueue(2)
+	q.push(queueAdv(t, "00:00:00:00:00:01", "1"))
+	done := make(chan struct{})
+	close(done)
+	q.deliver(done)
+	if _, ok := <-q.results; ok {
+		t.Errorf("Results channel is not closed")
+	}
+}
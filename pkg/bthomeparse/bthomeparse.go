@@ -0,0 +1,357 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bthomeparse parses BTHome v2 service data (UUID 0xFCD2), including
+// the AES-CCM encrypted variant.
+// See https://bthome.io/format/ for the format specification.
+package bthomeparse
+
+import (
+	"crypto/aes"
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// ServiceUUID is the 16-bit service UUID under which BTHome data is advertised.
+const ServiceUUID = 0xFCD2
+
+// ObjectID identifies the type of measurement in BTHome payload.
+type ObjectID uint8
+
+// List of the ObjectID values that are used directly by BTHomeData fields.
+// All other object IDs are accessible via BTHomeData.Objects.
+const (
+	PacketID            ObjectID = 0x00
+	Battery             ObjectID = 0x01
+	Temperature         ObjectID = 0x02
+	Humidity            ObjectID = 0x03
+	Pressure            ObjectID = 0x04
+	Illuminance         ObjectID = 0x05
+	Voltage             ObjectID = 0x0C
+	PM2_5               ObjectID = 0x0D
+	PM10                ObjectID = 0x0E
+	CO2                 ObjectID = 0x12
+	Motion              ObjectID = 0x21
+	HumidityUint8       ObjectID = 0x2E
+	TemperatureDeci     ObjectID = 0x45
+	VoltageDeci         ObjectID = 0x4A
+	TemperatureSint8    ObjectID = 0x57
+	TemperatureSint8035 ObjectID = 0x58
+)
+
+type objectType struct {
+	name   string
+	size   int // 0 means variable size with the length prefix byte.
+	signed bool
+	factor float64
+	unit   string
+}
+
+// https://bthome.io/format/#sensor-data
+var objectTypes = map[ObjectID]objectType{
+	0x00: {"packet id", 1, false, 1, ""},
+	0x01: {"battery", 1, false, 1, "%"},
+	0x02: {"temperature", 2, true, 0.01, "°C"},
+	0x03: {"humidity", 2, false, 0.01, "%"},
+	0x04: {"pressure", 3, false, 0.01, "hPa"},
+	0x05: {"illuminance", 3, false, 0.01, "lx"},
+	0x06: {"mass", 2, false, 0.01, "kg"},
+	0x07: {"mass", 2, false, 0.01, "lb"},
+	0x08: {"dewpoint", 2, true, 0.01, "°C"},
+	0x09: {"count", 1, false, 1, ""},
+	0x0A: {"energy", 3, false, 0.001, "kWh"},
+	0x0B: {"power", 3, false, 0.01, "W"},
+	0x0C: {"voltage", 2, false, 0.001, "V"},
+	0x0D: {"pm2.5", 2, false, 1, "µg/m³"},
+	0x0E: {"pm10", 2, false, 1, "µg/m³"},
+	0x0F: {"generic boolean", 1, false, 1, ""},
+	0x10: {"power", 1, false, 1, ""},
+	0x11: {"opening", 1, false, 1, ""},
+	0x12: {"co2", 2, false, 1, "ppm"},
+	0x13: {"tvoc", 2, false, 1, "µg/m³"},
+	0x14: {"moisture", 2, false, 0.01, "%"},
+	0x15: {"battery low", 1, false, 1, ""},
+	0x16: {"battery charging", 1, false, 1, ""},
+	0x17: {"carbon monoxide", 1, false, 1, ""},
+	0x18: {"cold", 1, false, 1, ""},
+	0x19: {"connectivity", 1, false, 1, ""},
+	0x1A: {"door", 1, false, 1, ""},
+	0x1B: {"garage door", 1, false, 1, ""},
+	0x1C: {"gas", 1, false, 1, ""},
+	0x1D: {"heat", 1, false, 1, ""},
+	0x1E: {"light", 1, false, 1, ""},
+	0x1F: {"lock", 1, false, 1, ""},
+	0x20: {"moisture", 1, false, 1, ""},
+	0x21: {"motion", 1, false, 1, ""},
+	0x22: {"moving", 1, false, 1, ""},
+	0x23: {"occupancy", 1, false, 1, ""},
+	0x24: {"plug", 1, false, 1, ""},
+	0x25: {"presence", 1, false, 1, ""},
+	0x26: {"problem", 1, false, 1, ""},
+	0x27: {"running", 1, false, 1, ""},
+	0x28: {"safety", 1, false, 1, ""},
+	0x29: {"smoke", 1, false, 1, ""},
+	0x2A: {"sound", 1, false, 1, ""},
+	0x2B: {"tamper", 1, false, 1, ""},
+	0x2C: {"vibration", 1, false, 1, ""},
+	0x2D: {"window", 1, false, 1, ""},
+	0x2E: {"humidity", 1, false, 1, "%"},
+	0x2F: {"moisture", 1, false, 1, "%"},
+	0x3A: {"button", 1, false, 1, ""},
+	0x3C: {"dimmer", 2, false, 1, ""},
+	0x3D: {"count", 2, false, 1, ""},
+	0x3E: {"count", 4, false, 1, ""},
+	0x3F: {"rotation", 2, true, 0.1, "°"},
+	0x40: {"distance", 2, false, 1, "mm"},
+	0x41: {"distance", 2, false, 0.1, "m"},
+	0x42: {"duration", 3, false, 0.001, "s"},
+	0x43: {"current", 2, false, 0.001, "A"},
+	0x44: {"speed", 2, false, 0.01, "m/s"},
+	0x45: {"temperature", 2, true, 0.1, "°C"},
+	0x46: {"uv index", 1, false, 0.1, ""},
+	0x47: {"volume", 2, false, 0.1, "L"},
+	0x48: {"volume", 2, false, 1, "mL"},
+	0x49: {"volume flow rate", 2, false, 0.001, "m³/h"},
+	0x4A: {"voltage", 2, false, 0.1, "V"},
+	0x4B: {"gas", 3, false, 0.001, "m³"},
+	0x4C: {"gas", 4, false, 0.001, "m³"},
+	0x4D: {"energy", 4, false, 0.001, "kWh"},
+	0x4E: {"volume", 4, false, 0.001, "L"},
+	0x4F: {"water", 4, false, 0.001, "L"},
+	0x50: {"timestamp", 4, false, 1, "s"},
+	0x51: {"acceleration", 2, false, 0.001, "m/s²"},
+	0x52: {"gyroscope", 2, false, 0.001, "°/s"},
+	0x53: {"text", 0, false, 1, ""},
+	0x54: {"raw", 0, false, 1, ""},
+	0x55: {"volume storage", 4, false, 0.001, "L"},
+	0x56: {"conductivity", 2, false, 1, "µS/cm"},
+	0x57: {"temperature", 1, true, 1, "°C"},
+	0x58: {"temperature", 1, true, 0.35, "°C"},
+	0x59: {"count", 1, true, 1, ""},
+	0x5A: {"count", 2, true, 1, ""},
+	0x5B: {"count", 4, true, 1, ""},
+	0x5C: {"power", 4, true, 0.01, "W"},
+	0x5D: {"current", 2, true, 0.001, "A"},
+	0x5E: {"direction", 2, false, 0.01, "°"},
+	0x5F: {"precipitation", 2, false, 0.1, "mm"},
+	0x60: {"channel", 1, false, 1, ""},
+	0xF0: {"device type id", 2, false, 1, ""},
+	0xF1: {"firmware version", 4, false, 1, ""},
+	0xF2: {"firmware version", 3, false, 1, ""},
+}
+
+// Object is a single decoded BTHome object.
+type Object struct {
+	ID    ObjectID
+	Name  string
+	Unit  string
+	Value float64 // Scaled value, not set for variable size objects.
+	Raw   []byte  // Raw object data without the object ID.
+}
+
+// BTHomeData contains a parsed BTHome advertisement.
+type BTHomeData struct {
+	Encrypted    bool
+	TriggerBased bool
+	Objects      []Object
+
+	// Values of the most common objects. If the advertisement contains
+	// multiple objects of the same type, the first one is used.
+	PacketID       *uint    // counter
+	Temperature    *float32 // C
+	Humidity       *float32 // RH %
+	Pressure       *float32 // hPa
+	Illuminance    *float32 // lx
+	BatteryLevel   *float32 // %
+	BatteryVoltage *float32 // V
+	CO2            *float32 // ppm
+	PM2_5          *float32 // µg/m³
+	PM10           *float32 // µg/m³
+	Motion         *bool
+}
+
+const (
+	encryptionFlag   = 0x01
+	triggerBasedFlag = 0x04
+	versionShift     = 5
+
+	counterSize = 4
+	micSize     = 4
+)
+
+// Parse takes BTHome Service Data field from BLE advertisement and parses the
+// content into BTHomeData. Key is the 16 bytes AES bind key, it's required
+// only when the advertisement is encrypted. Mac is the address of the
+// advertising device, used as part of the encryption nonce.
+func Parse(data []byte, mac net.HardwareAddr, key []byte) (*BTHomeData, error) {
+	if len(data) < 1 {
+		return nil, fmt.Errorf("got empty byte slice")
+	}
+	deviceInfo := data[0]
+	if version := deviceInfo >> versionShift; version != 2 {
+		return nil, fmt.Errorf("only BTHome v2 supported, got version: %d", version)
+	}
+	bd := &BTHomeData{
+		Encrypted:    deviceInfo&encryptionFlag != 0,
+		TriggerBased: deviceInfo&triggerBasedFlag != 0,
+	}
+	payload := data[1:]
+	if bd.Encrypted {
+		if key == nil {
+			return nil, fmt.Errorf("advertisement is encrypted but there is no bind key")
+		}
+		var err error
+		payload, err = decrypt(data, mac, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt: %v", err)
+		}
+	}
+	if err := parseObjects(bd, payload); err != nil {
+		return nil, err
+	}
+	return bd, nil
+}
+
+// https://bthome.io/encryption/
+func decrypt(data []byte, mac net.HardwareAddr, key []byte) ([]byte, error) {
+	if len(mac) != 6 {
+		return nil, fmt.Errorf("invalid MAC address: %s", mac)
+	}
+	if len(data) < 1+counterSize+micSize {
+		return nil, fmt.Errorf("encrypted payload too short: %d bytes", len(data))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bind key: %v", err)
+	}
+	ciphertext := data[1 : len(data)-counterSize-micSize]
+	counter := data[len(data)-counterSize-micSize : len(data)-micSize]
+	mic := data[len(data)-micSize:]
+
+	nonce := make([]byte, 0, 13)
+	nonce = append(nonce, mac...)
+	nonce = binary.LittleEndian.AppendUint16(nonce, ServiceUUID)
+	nonce = append(nonce, data[0])
+	nonce = append(nonce, counter...)
+	return ccmOpen(block, nonce, ciphertext, mic, nil)
+}
+
+func nilF32(v float64) *float32 {
+	f := float32(v)
+	return &f
+}
+
+func readUint(data []byte) uint64 {
+	var v uint64
+	for i := len(data) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(data[i])
+	}
+	return v
+}
+
+func readInt(data []byte) int64 {
+	shift := 64 - 8*len(data)
+	return int64(readUint(data)<<shift) >> shift
+}
+
+func parseObjects(bd *BTHomeData, payload []byte) error {
+	for len(payload) > 0 {
+		id := ObjectID(payload[0])
+		ot, ok := objectTypes[id]
+		if !ok {
+			return fmt.Errorf("unknown object id 0x%02x, can't parse the rest of payload", uint8(id))
+		}
+		payload = payload[1:]
+		size := ot.size
+		if size == 0 {
+			if len(payload) < 1 {
+				return fmt.Errorf("missing length of object 0x%02x", uint8(id))
+			}
+			size = int(payload[0])
+			payload = payload[1:]
+		}
+		if len(payload) < size {
+			return fmt.Errorf("object 0x%02x requires %d bytes, got %d", uint8(id), size, len(payload))
+		}
+		obj := Object{
+			ID:   id,
+			Name: ot.name,
+			Unit: ot.unit,
+			Raw:  payload[:size],
+		}
+		if ot.size != 0 {
+			if ot.signed {
+				obj.Value = float64(readInt(obj.Raw)) * ot.factor
+			} else {
+				obj.Value = float64(readUint(obj.Raw)) * ot.factor
+			}
+		}
+		payload = payload[size:]
+		bd.Objects = append(bd.Objects, obj)
+		setCommonField(bd, &obj)
+	}
+	return nil
+}
+
+func setCommonField(bd *BTHomeData, obj *Object) {
+	switch obj.ID {
+	case PacketID:
+		if bd.PacketID == nil {
+			v := uint(obj.Value)
+			bd.PacketID = &v
+		}
+	case Temperature, TemperatureDeci, TemperatureSint8, TemperatureSint8035:
+		if bd.Temperature == nil {
+			bd.Temperature = nilF32(obj.Value)
+		}
+	case Humidity, HumidityUint8:
+		if bd.Humidity == nil {
+			bd.Humidity = nilF32(obj.Value)
+		}
+	case Pressure:
+		if bd.Pressure == nil {
+			bd.Pressure = nilF32(obj.Value)
+		}
+	case Illuminance:
+		if bd.Illuminance == nil {
+			bd.Illuminance = nilF32(obj.Value)
+		}
+	case Battery:
+		if bd.BatteryLevel == nil {
+			bd.BatteryLevel = nilF32(obj.Value)
+		}
+	case Voltage, VoltageDeci:
+		if bd.BatteryVoltage == nil {
+			bd.BatteryVoltage = nilF32(obj.Value)
+		}
+	case CO2:
+		if bd.CO2 == nil {
+			bd.CO2 = nilF32(obj.Value)
+		}
+	case PM2_5:
+		if bd.PM2_5 == nil {
+			bd.PM2_5 = nilF32(obj.Value)
+		}
+	case PM10:
+		if bd.PM10 == nil {
+			bd.PM10 = nilF32(obj.Value)
+		}
+	case Motion:
+		if bd.Motion == nil {
+			v := obj.Value != 0
+			bd.Motion = &v
+		}
+	}
+}
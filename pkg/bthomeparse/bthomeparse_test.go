@@ -0,0 +1,175 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bthomeparse
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"testing"
+)
+
+func toB(s string) []byte {
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		panic("Got invalid hex string")
+	}
+	return decoded
+}
+
+func f32Ptr(v float32) *float32 { return &v }
+func uintPtr(v uint) *uint      { return &v }
+func boolPtr(v bool) *bool      { return &v }
+
+func ptrToStr[T any](v *T) string {
+	if v == nil {
+		return "nil"
+	}
+	return fmt.Sprint(*v)
+}
+
+func assertF32Eq(t *testing.T, param string, value, expected *float32) {
+	if value == nil || expected == nil {
+		if value != expected {
+			t.Errorf("%s not equal: value %s, expected %s", param, ptrToStr(value), ptrToStr(expected))
+		}
+	} else if math.Abs(float64(*value-*expected)) > 0.0001 {
+		t.Errorf("%s not equal: value %s, expected %s", param, ptrToStr(value), ptrToStr(expected))
+	}
+}
+
+func assertEq[T comparable](t *testing.T, param string, value, expected *T) {
+	if value == nil || expected == nil {
+		if value != expected {
+			t.Errorf("%s not equal: value %s, expected %s", param, ptrToStr(value), ptrToStr(expected))
+		}
+	} else if *value != *expected {
+		t.Errorf("%s not equal: value %s, expected %s", param, ptrToStr(value), ptrToStr(expected))
+	}
+}
+
+// Example key and device from https://bthome.io/encryption/
+var (
+	testMac, _ = net.ParseMAC("54:48:E6:8F:80:A5")
+	testKey    = toB("231d39c1d7cc1ab1aee224cd096db932")
+)
+
+func TestParsingValid(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     []byte
+		key      []byte
+		expected *BTHomeData
+		objects  int
+	}{
+		{"temperature and humidity", toB("4002CA0903BF13"), nil, &BTHomeData{
+			Temperature: f32Ptr(25.06),
+			Humidity:    f32Ptr(50.55),
+		}, 2},
+		{"encrypted", toB("41A47266C95F730011223378237214"), testKey, &BTHomeData{
+			Encrypted:   true,
+			Temperature: f32Ptr(25.06),
+			Humidity:    f32Ptr(50.55),
+		}, 2},
+		{"many objects", toB("440009016402CA0903BF1304138A0105138A140C0D0C1202042101"), nil, &BTHomeData{
+			TriggerBased:   true,
+			PacketID:       uintPtr(9),
+			BatteryLevel:   f32Ptr(100),
+			Temperature:    f32Ptr(25.06),
+			Humidity:       f32Ptr(50.55),
+			Pressure:       f32Ptr(1008.83),
+			Illuminance:    f32Ptr(13460.67),
+			BatteryVoltage: f32Ptr(3.085),
+			CO2:            f32Ptr(1026),
+			Motion:         boolPtr(true),
+		}, 9},
+		{"small variants", toB("4057F52E334A1F00"), nil, &BTHomeData{
+			Temperature:    f32Ptr(-11),
+			Humidity:       f32Ptr(51),
+			BatteryVoltage: f32Ptr(3.1),
+		}, 3},
+		{"variable size objects", toB("40530348656C02CA09"), nil, &BTHomeData{
+			Temperature: f32Ptr(25.06),
+		}, 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Parse(tc.data, testMac, tc.key)
+			if err != nil {
+				t.Fatalf("couldn't parse data: %v", err)
+			}
+			if res.Encrypted != tc.expected.Encrypted || res.TriggerBased != tc.expected.TriggerBased {
+				t.Errorf("flags not equal: value %v %v, expected %v %v", res.Encrypted, res.TriggerBased, tc.expected.Encrypted, tc.expected.TriggerBased)
+			}
+			if len(res.Objects) != tc.objects {
+				t.Errorf("unexpected number of objects: value %d, expected %d", len(res.Objects), tc.objects)
+			}
+			assertEq(t, "packet id", res.PacketID, tc.expected.PacketID)
+			assertF32Eq(t, "temperature", res.Temperature, tc.expected.Temperature)
+			assertF32Eq(t, "humidity", res.Humidity, tc.expected.Humidity)
+			assertF32Eq(t, "pressure", res.Pressure, tc.expected.Pressure)
+			assertF32Eq(t, "illuminance", res.Illuminance, tc.expected.Illuminance)
+			assertF32Eq(t, "battery level", res.BatteryLevel, tc.expected.BatteryLevel)
+			assertF32Eq(t, "battery voltage", res.BatteryVoltage, tc.expected.BatteryVoltage)
+			assertF32Eq(t, "co2", res.CO2, tc.expected.CO2)
+			assertEq(t, "motion", res.Motion, tc.expected.Motion)
+		})
+	}
+}
+
+func TestParsingInvalid(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		key  []byte
+	}{
+		{"empty", []byte{}, nil},
+		{"BTHome v1", toB("2002CA09"), nil},
+		{"unknown object", toB("40FE01"), nil},
+		{"truncated object", toB("4002CA"), nil},
+		{"encrypted without key", toB("41A47266C95F730011223378237214"), nil},
+		{"encrypted wrong key", toB("41A47266C95F730011223378237214"), toB("00000000000000000000000000000000")},
+		{"encrypted tampered", toB("41A47266C95F730011223378237215"), testKey},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.data, testMac, tc.key)
+			if err == nil {
+				t.Fatalf("Expected error, got success")
+			}
+		})
+	}
+}
+
+// Packet Vector #1 from RFC 3610.
+func TestCCMOpen(t *testing.T) {
+	block, err := aes.NewCipher(toB("C0C1C2C3C4C5C6C7C8C9CACBCCCDCECF"))
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+	encrypted := toB("588C979A61C663D2F066D0C2C0F989806D5F6B61DAC38417E8D12CFDF926E0")
+	res, err := ccmOpen(block, toB("00000003020100A0A1A2A3A4A5"), encrypted[:23], encrypted[23:], toB("0001020304050607"))
+	if err != nil {
+		t.Fatalf("Failed to decrypt: %v", err)
+	}
+	if expected := toB("08090A0B0C0D0E0F101112131415161718191A1B1C1D1E"); !bytes.Equal(res, expected) {
+		t.Errorf("Decrypted data not equal: value %X, expected %X", res, expected)
+	}
+}
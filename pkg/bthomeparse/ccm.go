@@ -0,0 +1,107 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bthomeparse
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+)
+
+// ccmOpen decrypts and verifies ciphertext with AES-CCM as specified in
+// RFC 3610. Go standard library doesn't implement CCM mode and it's simple
+// enough to not pull a dependency only for it.
+func ccmOpen(block cipher.Block, nonce, ciphertext, tag, aad []byte) ([]byte, error) {
+	const blockSize = 16
+	if block.BlockSize() != blockSize {
+		return nil, fmt.Errorf("CCM requires 128-bit block cipher")
+	}
+	l := 15 - len(nonce)
+	if l < 2 || l > 8 {
+		return nil, fmt.Errorf("invalid nonce length %d", len(nonce))
+	}
+	m := len(tag)
+	if m < 4 || m > 16 || m%2 != 0 {
+		return nil, fmt.Errorf("invalid tag length %d", m)
+	}
+	if len(aad) >= 0xFF00 {
+		return nil, fmt.Errorf("associated data too long")
+	}
+	if l < 8 && uint64(len(ciphertext)) >= uint64(1)<<(8*l) {
+		return nil, fmt.Errorf("ciphertext too long")
+	}
+
+	// Counter blocks: A_i = flags | nonce | i
+	counter := func(i uint64) []byte {
+		a := make([]byte, blockSize)
+		a[0] = byte(l - 1)
+		copy(a[1:], nonce)
+		var ctr [8]byte
+		binary.BigEndian.PutUint64(ctr[:], i)
+		copy(a[1+len(nonce):], ctr[8-l:])
+		return a
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	s := make([]byte, blockSize)
+	for i := 0; i < len(ciphertext); i += blockSize {
+		block.Encrypt(s, counter(uint64(i/blockSize)+1))
+		for j := i; j < len(ciphertext) && j < i+blockSize; j++ {
+			plaintext[j] = ciphertext[j] ^ s[j-i]
+		}
+	}
+
+	// CBC-MAC over B_0 | encoded aad | plaintext, each padded to block size.
+	var macInput []byte
+	b0 := make([]byte, blockSize)
+	b0[0] = byte(((m-2)/2)<<3 | (l - 1))
+	if len(aad) > 0 {
+		b0[0] |= 0x40
+	}
+	copy(b0[1:], nonce)
+	var msgLen [8]byte
+	binary.BigEndian.PutUint64(msgLen[:], uint64(len(plaintext)))
+	copy(b0[1+len(nonce):], msgLen[8-l:])
+	macInput = append(macInput, b0...)
+	if len(aad) > 0 {
+		macInput = append(macInput, byte(len(aad)>>8), byte(len(aad)))
+		macInput = append(macInput, aad...)
+		for len(macInput)%blockSize != 0 {
+			macInput = append(macInput, 0)
+		}
+	}
+	macInput = append(macInput, plaintext...)
+	for len(macInput)%blockSize != 0 {
+		macInput = append(macInput, 0)
+	}
+	x := make([]byte, blockSize)
+	for i := 0; i < len(macInput); i += blockSize {
+		for j := 0; j < blockSize; j++ {
+			x[j] ^= macInput[i+j]
+		}
+		block.Encrypt(x, x)
+	}
+
+	block.Encrypt(s, counter(0))
+	expectedTag := make([]byte, m)
+	for i := range expectedTag {
+		expectedTag[i] = x[i] ^ s[i]
+	}
+	if subtle.ConstantTimeCompare(expectedTag, tag) != 1 {
+		return nil, fmt.Errorf("message authentication failed")
+	}
+	return plaintext, nil
+}
@@ -18,9 +18,11 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/url"
 	"path"
 	"regexp"
 	"strings"
@@ -36,9 +38,78 @@ type Sink interface{}
 
 // Config contains the full parsed configuration for the application.
 type Config struct {
-	Adapter         string
+	Adapters        []string
+	Backend         ListenerBackend
+	ScanMode        ScanMode
+	DiscoveryFilter *DiscoveryFilter
+	PowerOnAdapter  bool
+	DeviceCache     DeviceCache
 	Sinks           []Sink
 	SensorAllowlist []net.HardwareAddr
+	Decoders        Decoders
+}
+
+// Decoders contains configuration of sensor advertisement decoders.
+type Decoders struct {
+	BTHome BTHomeDecoder
+	Ruuvi  RuuviDecoder
+}
+
+// BTHomeDecoder is configuration for the decoders.BTHomeDecoder.
+type BTHomeDecoder struct {
+	// Map from sensor MAC in the net.HardwareAddr.String() format to the
+	// AES bind key.
+	BindKeys map[string][]byte
+}
+
+// RuuviDecoder is configuration for the decoders.RuuviDecoder.
+type RuuviDecoder struct {
+	// Map from sensor MAC in the net.HardwareAddr.String() format to the
+	// AES key used by the data format 8.
+	EncryptionKeys map[string][]byte
+}
+
+// ListenerBackend represents the way BLE advertisements are received.
+type ListenerBackend int
+
+const (
+	// BLUEZ means advertisements are received from BlueZ over D-Bus.
+	BLUEZ ListenerBackend = iota
+
+	// HCI means advertisements are received directly from the raw HCI socket.
+	HCI
+)
+
+// ScanMode represents the way BlueZ is scanning for advertisements.
+type ScanMode int
+
+const (
+	// AUTO means advertisement monitor is used when supported, and discovery
+	// otherwise.
+	AUTO ScanMode = iota
+
+	// DISCOVERY means active discovery is used.
+	DISCOVERY
+
+	// MONITOR means passive advertisement monitor is used.
+	MONITOR
+)
+
+// DiscoveryFilter is configuration of the discovery filter set by the
+// blelistener.AdvListener. Nil fields mean BlueZ defaults.
+type DiscoveryFilter struct {
+	Transport     *string
+	DuplicateData *bool
+	RSSI          *int16
+	UUIDs         []string
+}
+
+// DeviceCache is configuration of the cache of devices in the
+// blelistener.AdvListener. Zero values mean defaults.
+type DeviceCache struct {
+	MaxSize            int
+	TTL                time.Duration
+	RemoveStaleDevices bool
 }
 
 // RateLimit is configruation for the rate limiting of sinks.
@@ -46,6 +117,13 @@ type RateLimit struct {
 	Max1In time.Duration
 }
 
+// Queue is configuration of the persistent queue of a sink.
+type Queue struct {
+	Directory string
+	MaxSize   int64 // In bytes
+	MaxAge    time.Duration
+}
+
 // PublicationFormat represents format of message published on MQTT topic.
 type PublicationFormat int
 
@@ -65,12 +143,53 @@ type MQTTSink struct {
 	ServerName                                string
 	ServerPort                                int
 	TLSConfig                                 *tls.Config
+	Queue                                     *Queue
+	HomeAssistant                             *HomeAssistant
+}
+
+// HomeAssistant is configuration of Home Assistant MQTT discovery in the
+// sink.MQTTSink.
+type HomeAssistant struct {
+	DiscoveryPrefix string
+	ExpireAfter     time.Duration
 }
 
 type CloudPubSubSink struct {
 	Name, Project, Topic, Device string
 	RateLimit                    *RateLimit
 	Creds                        *google.Credentials
+	Queue                        *Queue
+}
+
+// PrometheusSink is configuration for the sink.PrometheusSink.
+type PrometheusSink struct {
+	Name, ListenAddress, Path string
+	StaleAfter                time.Duration
+}
+
+// InfluxDBSink is configuration for the sink.InfluxDBSink.
+type InfluxDBSink struct {
+	Name, URL, Org, Bucket, Token, Measurement string
+	RateLimit                                  *RateLimit
+	Queue                                      *Queue
+}
+
+// HTTPSink is configuration for the sink.HTTPSink.
+type HTTPSink struct {
+	Name, URL   string
+	Format      PublicationFormat
+	Headers     map[string]string
+	BearerToken string
+	BasicAuth   *BasicAuth
+	TLSConfig   *tls.Config
+	MaxRetries  int
+	RateLimit   *RateLimit
+	Queue       *Queue
+}
+
+// BasicAuth contains credentials for the HTTP basic authentication.
+type BasicAuth struct {
+	UserName, Password string
 }
 
 // StdoutSink is configuration for sink.StdoutSink.
@@ -80,7 +199,7 @@ type StdoutSink struct {
 }
 
 var (
-	projectIDRE, deviceIDsRE, cloudPubSubTopicRE, clientIDRE *regexp.Regexp
+	projectIDRE, deviceIDsRE, cloudPubSubTopicRE, clientIDRE, headerNameRE, uuidRE *regexp.Regexp
 )
 
 func init() {
@@ -88,6 +207,8 @@ func init() {
 	deviceIDsRE = regexp.MustCompile(`[a-zA-Z][-a-zA-Z0-9._+~%]{2,254}`)
 	cloudPubSubTopicRE = regexp.MustCompile(`[a-zA-Z][-a-zA-Z0-9._+~%]{2,254}`)
 	clientIDRE = regexp.MustCompile(`[0-9a-zA-Z]{0,23}`)
+	headerNameRE = regexp.MustCompile(`^[-!#$%&'*+.^_|~0-9a-zA-Z` + "`" + `]+$`)
+	uuidRE = regexp.MustCompile(`^([0-9a-f]{4}|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})$`)
 }
 
 func joinPathWithAbs(basePath, filePath string) string {
@@ -113,6 +234,172 @@ func parseRateLimit(rateLimit *fRateLimit) (*RateLimit, error) {
 	return res, nil
 }
 
+func parseQueue(basePath string, queue *fQueue) (*Queue, error) {
+	if queue == nil {
+		return nil, nil
+	}
+	if queue.Directory == "" {
+		return nil, fmt.Errorf("directory is a required field")
+	}
+	res := &Queue{
+		Directory: joinPathWithAbs(basePath, queue.Directory),
+		MaxSize:   10 * 1024 * 1024,
+		MaxAge:    7 * 24 * time.Hour,
+	}
+	if queue.MaxSize != nil {
+		if *queue.MaxSize <= 0 {
+			return nil, fmt.Errorf("max_size must be positive, given: %d", *queue.MaxSize)
+		}
+		res.MaxSize = *queue.MaxSize
+	}
+	if queue.MaxAge != nil {
+		var err error
+		res.MaxAge, err = time.ParseDuration(*queue.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse max_age as duration: %v", err)
+		}
+		if res.MaxAge <= 0 {
+			return nil, fmt.Errorf("max_age must be positive")
+		}
+	}
+	return res, nil
+}
+
+// checkQueueDirectories verifies that sinks don't share queue directories.
+func checkQueueDirectories(sinks []Sink) error {
+	seen := make(map[string]bool)
+	for _, sink := range sinks {
+		var queue *Queue
+		switch s := sink.(type) {
+		case *MQTTSink:
+			queue = s.Queue
+		case *CloudPubSubSink:
+			queue = s.Queue
+		case *InfluxDBSink:
+			queue = s.Queue
+		case *HTTPSink:
+			queue = s.Queue
+		}
+		if queue == nil {
+			continue
+		}
+		dir := path.Clean(queue.Directory)
+		if seen[dir] {
+			return fmt.Errorf("queue directory '%s' is used by multiple sinks", queue.Directory)
+		}
+		seen[dir] = true
+	}
+	return nil
+}
+
+func parseHomeAssistant(homeAssistant *fHomeAssistant) (*HomeAssistant, error) {
+	if homeAssistant == nil {
+		return nil, nil
+	}
+	res := &HomeAssistant{
+		DiscoveryPrefix: "homeassistant",
+		ExpireAfter:     10 * time.Minute,
+	}
+	if homeAssistant.DiscoveryPrefix != nil {
+		prefix := *homeAssistant.DiscoveryPrefix
+		if prefix == "" || prefix[0] == '$' || strings.ContainsAny(prefix, "+#\u0000") {
+			return nil, fmt.Errorf("discovery_prefix is not a valid topic name, given: '%s'", prefix)
+		}
+		res.DiscoveryPrefix = strings.TrimSuffix(prefix, "/")
+	}
+	if homeAssistant.ExpireAfter != nil {
+		var err error
+		res.ExpireAfter, err = time.ParseDuration(*homeAssistant.ExpireAfter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse expire_after as duration: %v", err)
+		}
+		if res.ExpireAfter < 0 {
+			return nil, fmt.Errorf("expire_after can't be negative")
+		}
+	}
+	return res, nil
+}
+
+func parseAdapters(adapter interface{}) ([]string, error) {
+	var adapters []string
+	switch a := adapter.(type) {
+	case nil:
+		return []string{"hci0"}, nil
+	case string:
+		adapters = append(adapters, a)
+	case []interface{}:
+		seen := make(map[string]bool)
+		for _, v := range a {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("adapter names must be strings, given: %v", v)
+			}
+			if seen[name] {
+				return nil, fmt.Errorf("adapter '%s' specified multiple times", name)
+			}
+			seen[name] = true
+			adapters = append(adapters, name)
+		}
+	default:
+		return nil, fmt.Errorf("adapter must be a string or a list of strings, given: %v", a)
+	}
+	if len(adapters) == 0 {
+		return nil, fmt.Errorf("at least one adapter must be specified")
+	}
+	for _, name := range adapters {
+		if name == "" {
+			return nil, fmt.Errorf("adapter name can't be empty")
+		}
+	}
+	return adapters, nil
+}
+
+func parseDiscoveryFilter(filter *fDiscoveryFilter) (*DiscoveryFilter, error) {
+	if filter == nil {
+		return nil, nil
+	}
+	res := &DiscoveryFilter{
+		Transport:     filter.Transport,
+		DuplicateData: filter.DuplicateData,
+		RSSI:          filter.RSSI,
+	}
+	if res.Transport != nil && *res.Transport != "auto" && *res.Transport != "bredr" && *res.Transport != "le" {
+		return nil, fmt.Errorf("transport have to be either auto, bredr or le, given: '%s'", *res.Transport)
+	}
+	if res.RSSI != nil && (*res.RSSI < -127 || *res.RSSI > 20) {
+		return nil, fmt.Errorf("rssi must be between -127 and 20, given: %d", *res.RSSI)
+	}
+	for _, uuid := range filter.UUIDs {
+		uuid = strings.ToLower(uuid)
+		if !uuidRE.MatchString(uuid) {
+			return nil, fmt.Errorf("'%s' is not a valid 16-bit or 128-bit UUID", uuid)
+		}
+		res.UUIDs = append(res.UUIDs, uuid)
+	}
+	return res, nil
+}
+
+func parseDeviceCache(cache *fDeviceCache) (DeviceCache, error) {
+	res := DeviceCache{
+		MaxSize:            cache.MaxSize,
+		RemoveStaleDevices: cache.RemoveStaleDevices,
+	}
+	if res.MaxSize < 0 {
+		return DeviceCache{}, fmt.Errorf("max_size can't be negative, given: %d", res.MaxSize)
+	}
+	if cache.TTL != "" {
+		var err error
+		res.TTL, err = time.ParseDuration(cache.TTL)
+		if err != nil {
+			return DeviceCache{}, fmt.Errorf("failed to parse ttl as duration: %v", err)
+		}
+		if res.TTL < time.Second {
+			return DeviceCache{}, fmt.Errorf("ttl must be at least 1s")
+		}
+	}
+	return res, nil
+}
+
 func parseTLSConfig(config *fTLSConfig, defaultServerName string, basePath string) (*tls.Config, error) {
 	res := &tls.Config{
 		MinVersion:         tls.VersionTLS12,
@@ -197,6 +484,18 @@ func parseMQTTSink(basePath string, sinkID int, sink *fMQTTSink) (*MQTTSink, err
 		res.TLSConfig = tlsConfig
 	}
 
+	queue, err := parseQueue(basePath, sink.Queue)
+	if err != nil {
+		return nil, fmt.Errorf("sink %s: Failed to parse queue: %v", res.Name, err)
+	}
+	res.Queue = queue
+
+	homeAssistant, err := parseHomeAssistant(sink.HomeAssistant)
+	if err != nil {
+		return nil, fmt.Errorf("sink %s: Failed to parse home_assistant: %v", res.Name, err)
+	}
+	res.HomeAssistant = homeAssistant
+
 	return res, nil
 }
 
@@ -250,6 +549,172 @@ func parseCloudPubSubSink(basePath string, sinkID int, sink *fCloudPubSubSink) (
 	}
 	res.RateLimit = rateLimit
 
+	queue, err := parseQueue(basePath, sink.Queue)
+	if err != nil {
+		return nil, fmt.Errorf("sink %s: Failed to parse queue: %v", res.Name, err)
+	}
+	res.Queue = queue
+
+	return res, nil
+}
+
+func parsePrometheusSink(sinkID int, sink *fPrometheusSink) (*PrometheusSink, error) {
+	if sink == nil {
+		sink = &fPrometheusSink{}
+	}
+	res := &PrometheusSink{
+		ListenAddress: ":9877",
+		Path:          "/metrics",
+		StaleAfter:    5 * time.Minute,
+	}
+	if sink.Name == "" {
+		res.Name = fmt.Sprintf("unnamed-prometheus-sink-%d", sinkID)
+	} else {
+		res.Name = sink.Name
+	}
+	if sink.ListenAddress != nil {
+		if _, _, err := net.SplitHostPort(*sink.ListenAddress); err != nil {
+			return nil, fmt.Errorf("sink %s: listen_address must be in the host:port format: %v", res.Name, err)
+		}
+		res.ListenAddress = *sink.ListenAddress
+	}
+	if sink.Path != nil {
+		if !strings.HasPrefix(*sink.Path, "/") {
+			return nil, fmt.Errorf("sink %s: path must start with /, given: '%s'", res.Name, *sink.Path)
+		}
+		res.Path = *sink.Path
+	}
+	if sink.StaleAfter != nil {
+		var err error
+		res.StaleAfter, err = time.ParseDuration(*sink.StaleAfter)
+		if err != nil {
+			return nil, fmt.Errorf("sink %s: Failed to parse stale_after as duration: %v", res.Name, err)
+		}
+		if res.StaleAfter <= 0 {
+			return nil, fmt.Errorf("sink %s: stale_after must be positive", res.Name)
+		}
+	}
+	return res, nil
+}
+
+func parseInfluxDBSink(basePath string, sinkID int, sink *fInfluxDBSink) (*InfluxDBSink, error) {
+	if sink == nil {
+		sink = &fInfluxDBSink{}
+	}
+	res := &InfluxDBSink{}
+	if sink.Name == "" {
+		res.Name = fmt.Sprintf("unnamed-influxdb-sink-%d", sinkID)
+	} else {
+		res.Name = sink.Name
+	}
+
+	if u, err := url.Parse(sink.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("sink %s: url must be a valid http or https URL, given: '%s'", res.Name, sink.URL)
+	}
+	res.URL = strings.TrimSuffix(sink.URL, "/")
+	if sink.Org == "" {
+		return nil, fmt.Errorf("sink %s: org is a required field", res.Name)
+	}
+	res.Org = sink.Org
+	if sink.Bucket == "" {
+		return nil, fmt.Errorf("sink %s: bucket is a required field", res.Name)
+	}
+	res.Bucket = sink.Bucket
+	res.Token = sink.Token
+	if sink.Measurement == nil {
+		res.Measurement = "gbcsdpd"
+	} else if *sink.Measurement == "" || strings.HasPrefix(*sink.Measurement, "_") {
+		return nil, fmt.Errorf("sink %s: measurement can't be empty or start with _, given: '%s'", res.Name, *sink.Measurement)
+	} else {
+		res.Measurement = *sink.Measurement
+	}
+
+	rateLimit, err := parseRateLimit(sink.RateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("sink %s: Failed to parse rate limit: %v", res.Name, err)
+	}
+	res.RateLimit = rateLimit
+
+	queue, err := parseQueue(basePath, sink.Queue)
+	if err != nil {
+		return nil, fmt.Errorf("sink %s: Failed to parse queue: %v", res.Name, err)
+	}
+	res.Queue = queue
+	return res, nil
+}
+
+func parseHTTPSink(basePath string, sinkID int, sink *fHTTPSink) (*HTTPSink, error) {
+	if sink == nil {
+		sink = &fHTTPSink{}
+	}
+	res := &HTTPSink{}
+	if sink.Name == "" {
+		res.Name = fmt.Sprintf("unnamed-http-sink-%d", sinkID)
+	} else {
+		res.Name = sink.Name
+	}
+
+	u, err := url.Parse(sink.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("sink %s: url must be a valid http or https URL, given: '%s'", res.Name, sink.URL)
+	}
+	res.URL = sink.URL
+
+	if sink.Format == nil || *sink.Format == "JSON" {
+		res.Format = JSON
+	} else if *sink.Format == "BINARY" {
+		res.Format = BINARY
+	} else {
+		return nil, fmt.Errorf("sink %s: Format have to be either BINARY or JSON, given: '%s'", res.Name, *sink.Format)
+	}
+
+	for name := range sink.Headers {
+		if !headerNameRE.MatchString(name) {
+			return nil, fmt.Errorf("sink %s: '%s' is not a valid header name", res.Name, name)
+		}
+		if strings.EqualFold(name, "Authorization") && (sink.BearerToken != "" || sink.BasicAuth != nil) {
+			return nil, fmt.Errorf("sink %s: Authorization header can't be used together with bearer_token or basic_auth", res.Name)
+		}
+	}
+	res.Headers = sink.Headers
+	if sink.BearerToken != "" && sink.BasicAuth != nil {
+		return nil, fmt.Errorf("sink %s: Only one of bearer_token and basic_auth can be set", res.Name)
+	}
+	res.BearerToken = sink.BearerToken
+	if sink.BasicAuth != nil {
+		res.BasicAuth = &BasicAuth{
+			UserName: sink.BasicAuth.UserName,
+			Password: sink.BasicAuth.Password,
+		}
+	}
+
+	if u.Scheme == "https" {
+		tlsConfig, err := parseTLSConfig(&sink.TLS, u.Hostname(), basePath)
+		if err != nil {
+			return nil, fmt.Errorf("sink %s: Failed to parse tls config: %v", res.Name, err)
+		}
+		res.TLSConfig = tlsConfig
+	}
+
+	if sink.MaxRetries == nil {
+		res.MaxRetries = 3
+	} else if *sink.MaxRetries < 0 {
+		return nil, fmt.Errorf("sink %s: max_retries can't be negative, given: %d", res.Name, *sink.MaxRetries)
+	} else {
+		res.MaxRetries = *sink.MaxRetries
+	}
+
+	rateLimit, err := parseRateLimit(sink.RateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("sink %s: Failed to parse rate limit: %v", res.Name, err)
+	}
+	res.RateLimit = rateLimit
+
+	queue, err := parseQueue(basePath, sink.Queue)
+	if err != nil {
+		return nil, fmt.Errorf("sink %s: Failed to parse queue: %v", res.Name, err)
+	}
+	res.Queue = queue
 	return res, nil
 }
 
@@ -271,6 +736,28 @@ func parseStdoutSink(sinkID int, sink *fStdoutSink) (*StdoutSink, error) {
 	return res, nil
 }
 
+func parseAESKeys(keys map[string]string) (map[string][]byte, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	res := make(map[string][]byte)
+	for address, hexKey := range keys {
+		hwAddr, err := net.ParseMAC(address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key sensor address: %v", err)
+		}
+		key, err := hex.DecodeString(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("key for %s is not a valid hex string: %v", hwAddr, err)
+		}
+		if len(key) != 16 {
+			return nil, fmt.Errorf("key for %s must be 16 bytes long, got %d bytes", hwAddr, len(key))
+		}
+		res[hwAddr.String()] = key
+	}
+	return res, nil
+}
+
 // Read reads a configuration file defined in config_format.go and
 // parses it into easily digestable Config struct.
 func Read(configPath string) (*Config, error) {
@@ -291,11 +778,41 @@ func Read(configPath string) (*Config, error) {
 	}
 
 	config := &Config{}
-	if fconfig.Adapter == nil {
-		config.Adapter = "hci0"
+	adapters, err := parseAdapters(fconfig.Adapter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse adapter: %v", err)
+	}
+	config.Adapters = adapters
+	if fconfig.Backend == nil || *fconfig.Backend == "BLUEZ" {
+		config.Backend = BLUEZ
+	} else if *fconfig.Backend == "HCI" {
+		config.Backend = HCI
+	} else {
+		return nil, fmt.Errorf("backend have to be either BLUEZ or HCI, given: '%s'", *fconfig.Backend)
+	}
+	if fconfig.ScanMode == nil || *fconfig.ScanMode == "AUTO" {
+		config.ScanMode = AUTO
+	} else if *fconfig.ScanMode == "DISCOVERY" {
+		config.ScanMode = DISCOVERY
+	} else if *fconfig.ScanMode == "MONITOR" {
+		config.ScanMode = MONITOR
 	} else {
-		config.Adapter = *fconfig.Adapter
+		return nil, fmt.Errorf("scan_mode have to be either AUTO, DISCOVERY or MONITOR, given: '%s'", *fconfig.ScanMode)
+	}
+	discoveryFilter, err := parseDiscoveryFilter(fconfig.DiscoveryFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse discovery filter: %v", err)
+	}
+	if discoveryFilter != nil && config.ScanMode == MONITOR {
+		return nil, fmt.Errorf("discovery_filter can't be used with scan_mode MONITOR")
+	}
+	config.DiscoveryFilter = discoveryFilter
+	config.PowerOnAdapter = fconfig.PowerOnAdapter
+	deviceCache, err := parseDeviceCache(&fconfig.DeviceCache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse device cache: %v", err)
 	}
+	config.DeviceCache = deviceCache
 	for _, address := range fconfig.SensorAllowlist {
 		hwAddr, err := net.ParseMAC(address)
 		if err != nil {
@@ -303,6 +820,18 @@ func Read(configPath string) (*Config, error) {
 		}
 		config.SensorAllowlist = append(config.SensorAllowlist, hwAddr)
 	}
+	bindKeys, err := parseAESKeys(fconfig.Decoders.BTHome.BindKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse BTHome bind keys: %v", err)
+	}
+	config.Decoders.BTHome.BindKeys = bindKeys
+
+	encryptionKeys, err := parseAESKeys(fconfig.Decoders.Ruuvi.EncryptionKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Ruuvi encryption keys: %v", err)
+	}
+	config.Decoders.Ruuvi.EncryptionKeys = encryptionKeys
+
 	for i, sink := range fconfig.Sinks.MQTT {
 		mqttSink, err := parseMQTTSink(path.Dir(configPath), i, sink)
 		if err != nil {
@@ -317,6 +846,27 @@ func Read(configPath string) (*Config, error) {
 		}
 		config.Sinks = append(config.Sinks, cloudPubSubSink)
 	}
+	for i, sink := range fconfig.Sinks.InfluxDB {
+		influxDBSink, err := parseInfluxDBSink(path.Dir(configPath), i, sink)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse InfluxDB sink config: %v", err)
+		}
+		config.Sinks = append(config.Sinks, influxDBSink)
+	}
+	for i, sink := range fconfig.Sinks.HTTP {
+		httpSink, err := parseHTTPSink(path.Dir(configPath), i, sink)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse HTTP sink config: %v", err)
+		}
+		config.Sinks = append(config.Sinks, httpSink)
+	}
+	for i, sink := range fconfig.Sinks.Prometheus {
+		prometheusSink, err := parsePrometheusSink(i, sink)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Prometheus sink config: %v", err)
+		}
+		config.Sinks = append(config.Sinks, prometheusSink)
+	}
 	for i, sink := range fconfig.Sinks.Stdout {
 		stdoutSink, err := parseStdoutSink(i, sink)
 		if err != nil {
@@ -324,6 +874,9 @@ func Read(configPath string) (*Config, error) {
 		}
 		config.Sinks = append(config.Sinks, stdoutSink)
 	}
+	if err := checkQueueDirectories(config.Sinks); err != nil {
+		return nil, err
+	}
 
 	if len(config.Sinks) == 0 {
 		config.Sinks = append(config.Sinks, &StdoutSink{
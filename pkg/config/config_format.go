@@ -16,14 +16,91 @@ package config
 
 // Represents the config file, root for unmarshaling the TOML config
 type fConfig struct {
-	// Name of the bluetooth adapter to listen for publications, eg hci0
-	Adapter *string `toml:"adapter"` // default: hci0
+	// Name of the bluetooth adapter to listen for publications, eg hci0, or
+	// a list of names to listen on multiple adapters, eg ["hci0", "hci1"]
+	Adapter interface{} `toml:"adapter"` // default: hci0
+
+	// The way advertisements are received, either BLUEZ for BlueZ D-Bus API
+	// or HCI for raw HCI socket which doesn't require bluetoothd.
+	Backend *string `toml:"backend"` // default: BLUEZ
+
+	// How BlueZ scans for advertisements: DISCOVERY for active discovery,
+	// MONITOR for passive advertisement monitor, or AUTO to use monitor when
+	// supported by BlueZ and discovery otherwise.
+	ScanMode *string `toml:"scan_mode"` // default: AUTO
+
+	// Filter passed to BlueZ when discovery is used. If not set, BlueZ defaults
+	// are used.
+	DiscoveryFilter *fDiscoveryFilter `toml:"discovery_filter"`
+
+	// Whatever to power on the adapter when it's powered off, only with the
+	// BLUEZ backend
+	PowerOnAdapter bool `toml:"power_on_adapter"` // default: false
+
+	// Limits of the cache of devices seen by BlueZ, only with the BLUEZ
+	// backend
+	DeviceCache fDeviceCache `toml:"device_cache"`
 
 	// If none sinks are defined, a single default Stdout sink is created
 	Sinks fSinks `toml:"sinks"`
 
 	// Sensors MAC adresses allowlist. If emtpy, all sensors are allowed.
 	SensorAllowlist []string `toml:"sensor_allowlist"`
+
+	// Configuration of decoders of sensor advertisements
+	Decoders fDecoders `toml:"decoders"`
+}
+
+// Configuration of org.bluez.Adapter1.SetDiscoveryFilter, unset fields are
+// not passed to BlueZ
+type fDiscoveryFilter struct {
+	// Transport to discover on, either auto, bredr or le
+	Transport *string `toml:"transport"`
+
+	// Whatever to report every received advertisement and not only changes
+	DuplicateData *bool `toml:"duplicate_data"`
+
+	// Minimal RSSI in dBm of reported devices
+	RSSI *int16 `toml:"rssi"`
+
+	// List of service UUIDs, 16-bit or 128-bit, that reported devices must
+	// advertise
+	UUIDs []string `toml:"uuids"`
+}
+
+// Configuration of the cache of last advertisement of every device
+type fDeviceCache struct {
+	// Maximum number of cached devices
+	MaxSize int `toml:"max_size"` // default: 1024
+
+	// Duration, eg "10m", after which device that didn't change is dropped
+	// from the cache
+	TTL string `toml:"ttl"` // default: 10m
+
+	// Whatever to remove devices dropped from the cache after ttl from BlueZ.
+	// Sensors and paired, bonded, trusted or connected devices are never
+	// removed.
+	RemoveStaleDevices bool `toml:"remove_stale_devices"` // default: false
+}
+
+// Struct holds configuration of decoders that need it
+type fDecoders struct {
+	BTHome fBTHomeDecoder `toml:"bthome"`
+	Ruuvi  fRuuviDecoder  `toml:"ruuvi"`
+}
+
+// Configuration for decoding BTHome advertisements
+type fBTHomeDecoder struct {
+	// Map from sensor MAC address to the hex encoded 16 bytes AES bind key
+	// used to decrypt encrypted advertisements from the sensor
+	BindKeys map[string]string `toml:"bind_keys"`
+}
+
+// Configuration for decoding RuuviTag advertisements
+type fRuuviDecoder struct {
+	// Map from sensor MAC address to the hex encoded 16 bytes AES key used to
+	// decrypt advertisements in the encrypted data format 8
+	EncryptionKeys map[string]string `toml:"encryption_keys"`
 }
 
 // Struct holds list of sinks for publications
@@ -31,6 +108,9 @@ type fSinks struct {
 	MQTT        []*fMQTTSink        `toml:"mqtt"`
 	CloudPubSub []*fCloudPubSubSink `toml:"cloud_pubsub"`
 	Stdout      []*fStdoutSink      `toml:"stdout"`
+	Prometheus  []*fPrometheusSink  `toml:"prometheus"`
+	InfluxDB    []*fInfluxDBSink    `toml:"influxdb"`
+	HTTP        []*fHTTPSink        `toml:"http"`
 }
 
 // Configruation for publishing to stdout
@@ -41,6 +121,89 @@ type fStdoutSink struct {
 	RateLimit *fRateLimit `toml:"rate_limit"`
 }
 
+// Configuration for exposing the latest measurements to Prometheus
+type fPrometheusSink struct {
+	// Optional name of sink
+	Name string `toml:"name"`
+
+	// Address to serve metrics on, in the host:port format
+	ListenAddress *string `toml:"listen_address"` // default: :9877
+
+	// HTTP path of the metrics endpoint
+	Path *string `toml:"path"` // default: /metrics
+
+	// Sensors that didn't send a measurement for the duration are removed from
+	// metrics. Duration is string in the format for `time.ParseDuration`
+	StaleAfter *string `toml:"stale_after"` // default: 5m
+}
+
+// Configuration for writing to InfluxDB v2 with the /api/v2/write HTTP API
+type fInfluxDBSink struct {
+	// Optional name of sink
+	Name string `toml:"name"`
+
+	RateLimit *fRateLimit `toml:"rate_limit"`
+
+	// Base URL of the InfluxDB server, eg https://influxdb.example.com:8086
+	URL string `toml:"url"`
+
+	// Organization name
+	Org string `toml:"org"`
+
+	// Bucket to write measurements to
+	Bucket string `toml:"bucket"`
+
+	// API token with the write permission to the bucket
+	Token string `toml:"token"`
+
+	// Name of the InfluxDB measurement that points are written to
+	Measurement *string `toml:"measurement"` // default: gbcsdpd
+
+	// Persistent queue for publications when InfluxDB is unavailable. If not
+	// set, publications are dropped then.
+	Queue *fQueue `toml:"queue"`
+}
+
+// Configuration for POSTing publications to generic HTTP endpoint
+type fHTTPSink struct {
+	// Optional name of sink
+	Name string `toml:"name"`
+
+	RateLimit *fRateLimit `toml:"rate_limit"`
+
+	// URL to POST `MeasurementsPublication` messages to
+	URL string `toml:"url"`
+
+	// Format of posted `MeasurementsPublication` message. Can be either BINARY or JSON
+	Format *string `toml:"format"` // default: JSON
+
+	// Additional headers to send with every request
+	Headers map[string]string `toml:"headers"`
+
+	// Token to send in the `Authorization: Bearer` header
+	BearerToken string `toml:"bearer_token"`
+
+	// Credentials for the basic authentication, can't be used together with
+	// BearerToken
+	BasicAuth *fBasicAuth `toml:"basic_auth"`
+
+	// TLS configuration for connection, used for https URLs.
+	TLS fTLSConfig `toml:"tls"`
+
+	// How many times to retry failed requests before giving up on the
+	// publication, or queuing it when Queue is set
+	MaxRetries *int `toml:"max_retries"` // default: 3
+
+	// Persistent queue for publications when the endpoint is unavailable. If
+	// not set, publications are dropped then.
+	Queue *fQueue `toml:"queue"`
+}
+
+type fBasicAuth struct {
+	UserName string `toml:"username"`
+	Password string `toml:"password"`
+}
+
 // Configuration for publishing to generic MQTT server
 type fMQTTSink struct {
 	// Optional name of sink
@@ -75,6 +238,28 @@ type fMQTTSink struct {
 
 	// TLS configuration for connection, used when EnableTLS is true.
 	TLS fTLSConfig `toml:"tls"`
+
+	// Persistent queue for publications when the server is unavailable. If
+	// not set, publications are dropped then. Measurements are published with
+	// QoS 1 when set.
+	Queue *fQueue `toml:"queue"`
+
+	// Home Assistant MQTT discovery. When set, the latest measurement of every
+	// sensor is also published as JSON to the <topic>/<sensor MAC> state
+	// topic, and sensors are announced to Home Assistant.
+	HomeAssistant *fHomeAssistant `toml:"home_assistant"`
+}
+
+// Configuration of Home Assistant MQTT discovery, see
+// https://www.home-assistant.io/integrations/mqtt/#mqtt-discovery
+type fHomeAssistant struct {
+	// Discovery topic prefix configured in Home Assistant
+	DiscoveryPrefix *string `toml:"discovery_prefix"` // default: homeassistant
+
+	// Home Assistant marks sensor unavailable when it doesn't receive
+	// measurement for the duration. Duration is string in the format for
+	// `time.ParseDuration`, 0 to never expire
+	ExpireAfter *string `toml:"expire_after"` // default: 10m
 }
 
 // Configuration for publishing to Google Cloud Pub/Sub
@@ -95,6 +280,10 @@ type fCloudPubSubSink struct {
 
 	// Path to service account credentials file
 	Creds *string `toml:"creds"`
+
+	// Persistent queue for publications when Cloud Pub/Sub is unavailable.
+	// If not set, publications are dropped then.
+	Queue *fQueue `toml:"queue"`
 }
 
 // Configuration for publishing rate limitting
@@ -104,6 +293,22 @@ type fRateLimit struct {
 	Max1In string `toml:"max_1_in"`
 }
 
+// Configuration of the persistent queue that stores publications on disk while
+// the destination is unavailable and replays them in order once it recovers
+type fQueue struct {
+	// Directory to store the queue in, relative to the config file. Every
+	// sink must use a different directory.
+	Directory string `toml:"directory"`
+
+	// Maximum total size of queued publications in bytes, the oldest
+	// publications are dropped when it's exceeded
+	MaxSize *int64 `toml:"max_size"` // default: 10485760 (10MiB)
+
+	// Maximum age of queued publications, older are dropped. Duration is
+	// string in the format for `time.ParseDuration`, eg: 24h
+	MaxAge *string `toml:"max_age"` // default: 168h
+}
+
 type fTLSConfig struct {
 	// root certificate authorities, if empty, the systems default is used
 	CACerts *string `toml:"ca_certs"`
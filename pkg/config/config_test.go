@@ -20,6 +20,7 @@ import (
 	"crypto/x509"
 	"io/ioutil"
 	"net"
+	"path"
 	"testing"
 	"time"
 
@@ -59,6 +60,10 @@ func caCertsTrans(cp *x509.CertPool) [][]byte {
 	return cp.Subjects()
 }
 
+func stringPtr(v string) *string { return &v }
+func boolPtr(v bool) *bool       { return &v }
+func int16Ptr(v int16) *int16    { return &v }
+
 func cmpConfig(actual, expected *Config) string {
 	return cmp.Diff(actual, expected,
 		cmp.Transformer("CertPool", caCertsTrans),
@@ -75,7 +80,21 @@ func TestParsingCorrect(t *testing.T) {
 		t.Fatalf("Failed to parse config: %v", err)
 	}
 	expectedConfig := &Config{
-		Adapter: "hci1",
+		Adapters:       []string{"hci1", "hci2"},
+		Backend:        HCI,
+		ScanMode:       DISCOVERY,
+		PowerOnAdapter: true,
+		DeviceCache: DeviceCache{
+			MaxSize:            256,
+			TTL:                30 * time.Minute,
+			RemoveStaleDevices: true,
+		},
+		DiscoveryFilter: &DiscoveryFilter{
+			Transport:     stringPtr("le"),
+			DuplicateData: boolPtr(true),
+			RSSI:          int16Ptr(-90),
+			UUIDs:         []string{"181a", "6e400001-b5a3-f393-e0a9-e50e24dcca9e"},
+		},
 		Sinks: []Sink{
 			&MQTTSink{
 				Name:       "mqtt sink 1",
@@ -94,6 +113,15 @@ func TestParsingCorrect(t *testing.T) {
 					ServerName:         "tls_overriden.gcp.com",
 					RootCAs:            readCACerts(t, "testdata/test1/myCa.pem"),
 				},
+				Queue: &Queue{
+					Directory: "testdata/test1/queue/mqtt",
+					MaxSize:   1048576,
+					MaxAge:    24 * time.Hour,
+				},
+				HomeAssistant: &HomeAssistant{
+					DiscoveryPrefix: "ha",
+					ExpireAfter:     10 * time.Minute,
+				},
 			},
 			&CloudPubSubSink{
 				Name:      "cloud pubsub sink 1",
@@ -102,6 +130,40 @@ func TestParsingCorrect(t *testing.T) {
 				Topic:     "topic1",
 				Creds:     readGoogleCredentials(t, "testdata/test1/creds.json"),
 				RateLimit: &RateLimit{Max1In: 120 * time.Second},
+				Queue: &Queue{
+					Directory: "/var/lib/gbcsdpd/pubsub",
+					MaxSize:   10 * 1024 * 1024,
+					MaxAge:    7 * 24 * time.Hour,
+				},
+			},
+			&InfluxDBSink{
+				Name:        "influxdb sink 1",
+				RateLimit:   &RateLimit{Max1In: 30 * time.Second},
+				URL:         "https://influxdb.example.com:8086",
+				Org:         "building",
+				Bucket:      "telemetry",
+				Token:       "secret-token",
+				Measurement: "gbcsdpd",
+			},
+			&HTTPSink{
+				Name:      "http sink 1",
+				URL:       "https://hooks.example.com/gbcsdpd",
+				Format:    BINARY,
+				Headers:   map[string]string{"X-Gateway": "attic"},
+				BasicAuth: &BasicAuth{UserName: "gateway", Password: "hunter2"},
+				TLSConfig: &tls.Config{
+					MinVersion:         tls.VersionTLS12,
+					ClientSessionCache: tls.NewLRUClientSessionCache(10),
+					ServerName:         "hooks.example.com",
+					RootCAs:            readCACerts(t, "testdata/test1/myCa.pem"),
+				},
+				MaxRetries: 5,
+			},
+			&PrometheusSink{
+				Name:          "prometheus sink 1",
+				ListenAddress: "127.0.0.1:9100",
+				Path:          "/metrics",
+				StaleAfter:    10 * time.Minute,
 			},
 			&StdoutSink{
 				Name:      "stdout sink 1",
@@ -116,6 +178,18 @@ func TestParsingCorrect(t *testing.T) {
 			[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
 			[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xf1},
 		},
+		Decoders: Decoders{
+			BTHome: BTHomeDecoder{
+				BindKeys: map[string][]byte{
+					"54:48:e6:8f:80:a5": {0x23, 0x1d, 0x39, 0xc1, 0xd7, 0xcc, 0x1a, 0xb1, 0xae, 0xe2, 0x24, 0xcd, 0x09, 0x6d, 0xb9, 0x32},
+				},
+			},
+			Ruuvi: RuuviDecoder{
+				EncryptionKeys: map[string][]byte{
+					"cb:b8:33:4c:88:4f": {0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f},
+				},
+			},
+		},
 	}
 	if diff := cmpConfig(config, expectedConfig); diff != "" {
 		t.Errorf("unexpected difference:\n%v", diff)
@@ -128,7 +202,7 @@ func TestParsingEmpty(t *testing.T) {
 		t.Fatalf("Failed to parse config: %v", err)
 	}
 	expectedConfig := &Config{
-		Adapter: "hci0",
+		Adapters: []string{"hci0"},
 		Sinks: []Sink{
 			&StdoutSink{
 				Name: "default-sink",
@@ -147,7 +221,7 @@ func TestParsingEmptySink(t *testing.T) {
 		t.Fatalf("Failed to parse config: %v", err)
 	}
 	expectedConfig := &Config{
-		Adapter: "hci0",
+		Adapters: []string{"hci0"},
 		Sinks: []Sink{
 			&StdoutSink{
 				Name: "unnamed-stdout-sink-0",
@@ -158,3 +232,38 @@ func TestParsingEmptySink(t *testing.T) {
 		t.Errorf("unexpected difference:\n%v", diff)
 	}
 }
+
+func TestParsingSharedQueueDirectory(t *testing.T) {
+	configPath := path.Join(t.TempDir(), "config.toml")
+	if err := ioutil.WriteFile(configPath, []byte(`
+[[sinks.mqtt]]
+topic = "/measurements"
+server_name = "localhost"
+queue.directory = "queue"
+
+[[sinks.mqtt]]
+topic = "/measurements2"
+server_name = "localhost"
+queue.directory = "./queue/"
+`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := Read(configPath); err == nil {
+		t.Errorf("Expected error for queue directory shared by sinks")
+	}
+}
+
+func TestParsingMonitorWithDiscoveryFilter(t *testing.T) {
+	configPath := path.Join(t.TempDir(), "config.toml")
+	if err := ioutil.WriteFile(configPath, []byte(`
+scan_mode = "MONITOR"
+
+[discovery_filter]
+transport = "le"
+`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := Read(configPath); err == nil {
+		t.Errorf("Expected error for discovery_filter with scan_mode MONITOR")
+	}
+}
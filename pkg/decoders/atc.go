@@ -0,0 +1,67 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decoders
+
+import (
+	"fmt"
+
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/atcparse"
+	"github.com/p2004a/gbcsdpd/pkg/blelistener"
+)
+
+const (
+	environmentalSensingServiceUUID = 0x181a
+)
+
+// ATCDecoder decodes advertisements of Xiaomi thermometers running the ATC1441
+// or pvvx custom firmware.
+type ATCDecoder struct {
+	filter Filter
+}
+
+// Name implements Decoder.
+func (d *ATCDecoder) Name() string {
+	return "atc"
+}
+
+// Filter implements Decoder.
+func (d *ATCDecoder) Filter() *Filter {
+	return &d.filter
+}
+
+// Decode implements Decoder.
+func (d *ATCDecoder) Decode(adv *blelistener.Advertisement) (*api.Measurement, error) {
+	atcData, err := atcparse.Parse(adv.ServiceData[blelistener.ServiceDataUUID(environmentalSensingServiceUUID)])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse atc data: %v", err)
+	}
+	m := newMeasurement(adv)
+	m.Temperature = nilToNaN(atcData.Temperature)
+	m.Humidity = nilToNaN(atcData.Humidity)
+	m.BatteryVoltage = nilToNaN(atcData.BatteryVoltage)
+	if atcData.BatteryLevel != nil {
+		m.BatteryLevel = float32(*atcData.BatteryLevel)
+	}
+	m.MeasurementSequenceNumber = uintToUint32(atcData.MeasurementCounter)
+	return m, nil
+}
+
+// NewATCDecoder creates new ATCDecoder.
+func NewATCDecoder() *ATCDecoder {
+	return &ATCDecoder{
+		filter: Filter{ServiceDataUUIDs: []string{blelistener.ServiceDataUUID(environmentalSensingServiceUUID)}},
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decoders
+
+import (
+	"fmt"
+
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/blelistener"
+	"github.com/p2004a/gbcsdpd/pkg/bthomeparse"
+	"github.com/p2004a/gbcsdpd/pkg/config"
+)
+
+// BTHomeDecoder decodes BTHome v2 advertisements.
+type BTHomeDecoder struct {
+	filter   Filter
+	bindKeys map[string][]byte
+}
+
+// Name implements Decoder.
+func (d *BTHomeDecoder) Name() string {
+	return "bthome"
+}
+
+// Filter implements Decoder.
+func (d *BTHomeDecoder) Filter() *Filter {
+	return &d.filter
+}
+
+// Decode implements Decoder.
+func (d *BTHomeDecoder) Decode(adv *blelistener.Advertisement) (*api.Measurement, error) {
+	data := adv.ServiceData[blelistener.ServiceDataUUID(bthomeparse.ServiceUUID)]
+	key := d.bindKeys[adv.Address.String()]
+	bthomeData, err := bthomeparse.Parse(data, adv.Address, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bthome data: %v", err)
+	}
+	// Sensors with a bind key must always encrypt, otherwise anybody could
+	// spoof their measurements with plain advertisements.
+	if key != nil && !bthomeData.Encrypted {
+		return nil, fmt.Errorf("got unencrypted advertisement from sensor with bind key")
+	}
+	m := newMeasurement(adv)
+	m.Temperature = nilToNaN(bthomeData.Temperature)
+	m.Humidity = nilToNaN(bthomeData.Humidity)
+	m.Pressure = nilToNaN(bthomeData.Pressure)
+	m.Illuminance = nilToNaN(bthomeData.Illuminance)
+	m.BatteryVoltage = nilToNaN(bthomeData.BatteryVoltage)
+	m.BatteryLevel = nilToNaN(bthomeData.BatteryLevel)
+	m.Co2 = nilToNaN(bthomeData.CO2)
+	m.Pm2_5 = nilToNaN(bthomeData.PM2_5)
+	m.Pm10 = nilToNaN(bthomeData.PM10)
+	m.Motion = bthomeData.Motion
+	m.MeasurementSequenceNumber = uintToUint32(bthomeData.PacketID)
+	return m, nil
+}
+
+// NewBTHomeDecoder creates new BTHomeDecoder.
+func NewBTHomeDecoder(config *config.BTHomeDecoder) *BTHomeDecoder {
+	return &BTHomeDecoder{
+		filter:   Filter{ServiceDataUUIDs: []string{blelistener.ServiceDataUUID(bthomeparse.ServiceUUID)}},
+		bindKeys: config.BindKeys,
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package decoders turns BLE advertisements into api.Measurement objects.
+//
+// Every supported sensor family is implemented as a Decoder that declares
+// which advertisements it is interested in via Filter and converts them into
+// measurements. Decoders are collected in a Registry that the daemon consults
+// for every received advertisement.
+package decoders
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/blelistener"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Filter describes which advertisements are passed to a Decoder. Advertisement
+// matches the filter if it matches any of the criteria.
+type Filter struct {
+	// Manufacturer IDs present in the ManufacturerData.
+	ManufacturerIDs []uint16
+
+	// Service UUIDs present in the ServiceData, in the lowercase 128-bit
+	// string format used by BlueZ, see blelistener.ServiceDataUUID.
+	ServiceDataUUIDs []string
+
+	// Prefixes of the advertised local name.
+	LocalNamePrefixes []string
+}
+
+// Matches returns whatever the advertisement matches the filter.
+func (f *Filter) Matches(adv *blelistener.Advertisement) bool {
+	for _, id := range f.ManufacturerIDs {
+		if _, ok := adv.ManufacturerData[id]; ok {
+			return true
+		}
+	}
+	for _, uuid := range f.ServiceDataUUIDs {
+		if _, ok := adv.ServiceData[uuid]; ok {
+			return true
+		}
+	}
+	for _, prefix := range f.LocalNamePrefixes {
+		if adv.Name != "" && strings.HasPrefix(adv.Name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MonitorPatterns returns advertisement monitor patterns matching the same
+// advertisements as the filter. Local names are matched only when complete.
+func (f *Filter) MonitorPatterns() ([]blelistener.MonitorPattern, error) {
+	var patterns []blelistener.MonitorPattern
+	for _, id := range f.ManufacturerIDs {
+		patterns = append(patterns, blelistener.ManufacturerDataPattern(id))
+	}
+	for _, uuid := range f.ServiceDataUUIDs {
+		p, err := blelistener.ServiceDataPattern(uuid)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	for _, prefix := range f.LocalNamePrefixes {
+		patterns = append(patterns, blelistener.LocalNamePattern(prefix))
+	}
+	return patterns, nil
+}
+
+// Decoder decodes sensor data embedded in BLE advertisements.
+type Decoder interface {
+	// Name returns a short human readable name of the decoder used in logs.
+	Name() string
+
+	// Filter returns the filter selecting advertisements passed to Decode.
+	Filter() *Filter
+
+	// Decode parses the advertisement into a measurement. It's called only
+	// for advertisements matching the Filter.
+	Decode(adv *blelistener.Advertisement) (*api.Measurement, error)
+}
+
+// Registry holds a list of decoders and dispatches advertisements to them.
+type Registry struct {
+	decoders []Decoder
+}
+
+// Register adds a new decoder to the registry. Decoders registered earlier
+// take precedence when multiple decoders match the same advertisement.
+func (r *Registry) Register(d Decoder) {
+	r.decoders = append(r.decoders, d)
+}
+
+// Decoders returns all registered decoders.
+func (r *Registry) Decoders() []Decoder {
+	return r.decoders
+}
+
+// Match returns the first registered Decoder matching the advertisement or
+// nil if there isn't any.
+func (r *Registry) Match(adv *blelistener.Advertisement) Decoder {
+	for _, d := range r.decoders {
+		if d.Filter().Matches(adv) {
+			return d
+		}
+	}
+	return nil
+}
+
+// MonitorPatterns returns advertisement monitor patterns matching
+// advertisements of all registered decoders.
+func (r *Registry) MonitorPatterns() ([]blelistener.MonitorPattern, error) {
+	var patterns []blelistener.MonitorPattern
+	for _, d := range r.decoders {
+		p, err := d.Filter().MonitorPatterns()
+		if err != nil {
+			return nil, fmt.Errorf("decoder %s: %v", d.Name(), err)
+		}
+		patterns = append(patterns, p...)
+	}
+	return patterns, nil
+}
+
+// NewRegistry creates a new Registry with the given decoders registered.
+func NewRegistry(decoders ...Decoder) *Registry {
+	r := &Registry{}
+	for _, d := range decoders {
+		r.Register(d)
+	}
+	return r
+}
+
+// newMeasurement returns a measurement for the advertising sensor with all
+// values set to NaN, which indicates that they are not available.
+func newMeasurement(adv *blelistener.Advertisement) *api.Measurement {
+	nan := float32(math.NaN())
+	var rssi *float32
+	if adv.RSSI != nil {
+		v := float32(*adv.RSSI)
+		rssi = &v
+	}
+	txPower := nan
+	if adv.TxPower != nil {
+		txPower = float32(*adv.TxPower)
+	}
+	var timestamp *timestamppb.Timestamp
+	if !adv.ReceivedAt.IsZero() {
+		timestamp = timestamppb.New(adv.ReceivedAt)
+	}
+	return &api.Measurement{
+		SensorMac:      adv.Address.String(),
+		Timestamp:      timestamp,
+		Temperature:    nan,
+		Humidity:       nan,
+		Pressure:       nan,
+		Illuminance:    nan,
+		BatteryVoltage: nan,
+		BatteryLevel:   nan,
+		TxPower:        txPower,
+		Co2:            nan,
+		Pm1:            nan,
+		Pm2_5:          nan,
+		Pm4:            nan,
+		Pm10:           nan,
+		VocIndex:       nan,
+		NoxIndex:       nan,
+		AccelerationX:  nan,
+		AccelerationY:  nan,
+		AccelerationZ:  nan,
+		Rssi:           rssi,
+		Adapter:        adv.Adapter,
+	}
+}
+
+func nilToNaN(value *float32) float32 {
+	if value == nil {
+		return float32(math.NaN())
+	}
+	return *value
+}
+
+func uintToUint32(value *uint) *uint32 {
+	if value == nil {
+		return nil
+	}
+	v := uint32(*value)
+	return &v
+}
@@ -0,0 +1,204 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decoders
+
+import (
+	"encoding/hex"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/blelistener"
+	"github.com/p2004a/gbcsdpd/pkg/config"
+)
+
+func toB(s string) []byte {
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		panic("Got invalid hex string")
+	}
+	return decoded
+}
+
+type fakeDecoder struct {
+	name   string
+	filter Filter
+}
+
+func (d *fakeDecoder) Name() string    { return d.name }
+func (d *fakeDecoder) Filter() *Filter { return &d.filter }
+func (d *fakeDecoder) Decode(adv *blelistener.Advertisement) (*api.Measurement, error) {
+	return &api.Measurement{SensorMac: adv.Address.String()}, nil
+}
+
+func TestRegistryMatch(t *testing.T) {
+	registry := NewRegistry(
+		&fakeDecoder{name: "manufacturer", filter: Filter{ManufacturerIDs: []uint16{0x1234}}},
+		&fakeDecoder{name: "service", filter: Filter{ServiceDataUUIDs: []string{blelistener.ServiceDataUUID(0x181a)}}},
+		&fakeDecoder{name: "name", filter: Filter{LocalNamePrefixes: []string{"ATC_"}}},
+	)
+	cases := []struct {
+		name     string
+		adv      blelistener.Advertisement
+		expected string
+	}{
+		{"manufacturer data", blelistener.Advertisement{
+			ManufacturerData: blelistener.ManufacturerData{0x1234: {0x01}},
+		}, "manufacturer"},
+		{"service data", blelistener.Advertisement{
+			ServiceData: blelistener.ServiceData{"0000181a-0000-1000-8000-00805f9b34fb": {0x01}},
+		}, "service"},
+		{"local name", blelistener.Advertisement{Name: "ATC_123456"}, "name"},
+		{"first registered wins", blelistener.Advertisement{
+			Name:             "ATC_123456",
+			ManufacturerData: blelistener.ManufacturerData{0x1234: {0x01}},
+		}, "manufacturer"},
+		{"no match", blelistener.Advertisement{
+			Name:             "Phone",
+			ManufacturerData: blelistener.ManufacturerData{0x004c: {0x01}},
+		}, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := registry.Match(&tc.adv)
+			name := ""
+			if d != nil {
+				name = d.Name()
+			}
+			if name != tc.expected {
+				t.Errorf("matched wrong decoder: got '%s', expected '%s'", name, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRuuviDecoder(t *testing.T) {
+	rssi := int16(-70)
+	adv := &blelistener.Advertisement{
+		Address: net.HardwareAddr{0xcb, 0xb8, 0x33, 0x4c, 0x88, 0x4f},
+		Adapter: "hci1",
+		RSSI:    &rssi,
+		ManufacturerData: blelistener.ManufacturerData{
+			ruuviManufacturerID: toB("0512FC5394C37C0004FFFC040CAC364200CDCBB8334C884F"),
+		},
+	}
+	d := NewRuuviDecoder(&config.RuuviDecoder{})
+	if !d.Filter().Matches(adv) {
+		t.Fatalf("RuuviDecoder doesn't match ruuvi advertisement")
+	}
+	m, err := d.Decode(adv)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if m.SensorMac != "cb:b8:33:4c:88:4f" || math.Abs(float64(m.Temperature)-24.3) > 0.0001 {
+		t.Errorf("unexpected measurement: %v", m)
+	}
+	if m.TxPower != 4 || m.GetMovementCounter() != 66 || m.GetMeasurementSequenceNumber() != 205 ||
+		math.Abs(float64(m.AccelerationZ)-1.036) > 0.0001 {
+		t.Errorf("unexpected motion info: %v", m)
+	}
+	if m.GetRssi() != -70 || m.Adapter != "hci1" {
+		t.Errorf("unexpected reception info: rssi %v, adapter %s", m.Rssi, m.Adapter)
+	}
+
+	adv.ManufacturerData[ruuviManufacturerID] = toB("537FFF")
+	if _, err := d.Decode(adv); err == nil {
+		t.Errorf("Expected error for unsupported format, got success")
+	}
+}
+
+func TestBTHomeDecoderRequiresEncryption(t *testing.T) {
+	mac := net.HardwareAddr{0x54, 0x48, 0xe6, 0x8f, 0x80, 0xa5}
+	d := NewBTHomeDecoder(&config.BTHomeDecoder{
+		BindKeys: map[string][]byte{mac.String(): toB("231d39c1d7cc1ab1aee224cd096db932")},
+	})
+	adv := &blelistener.Advertisement{
+		Address: mac,
+		ServiceData: blelistener.ServiceData{
+			blelistener.ServiceDataUUID(0xfcd2): toB("41A47266C95F730011223378237214"),
+		},
+	}
+	m, err := d.Decode(adv)
+	if err != nil {
+		t.Fatalf("Failed to decode encrypted advertisement: %v", err)
+	}
+	if math.Abs(float64(m.Temperature)-25.06) > 0.0001 {
+		t.Errorf("unexpected measurement: %v", m)
+	}
+
+	adv.ServiceData[blelistener.ServiceDataUUID(0xfcd2)] = toB("4002CA0903BF13")
+	if _, err := d.Decode(adv); err == nil {
+		t.Errorf("Expected error for unencrypted advertisement from sensor with bind key, got success")
+	}
+
+	adv.Address = net.HardwareAddr{0x54, 0x48, 0xe6, 0x8f, 0x80, 0xa6}
+	if _, err := d.Decode(adv); err != nil {
+		t.Errorf("Failed to decode unencrypted advertisement from sensor without bind key: %v", err)
+	}
+}
+
+func TestDeduplicator(t *testing.T) {
+	seq := func(v uint32) *uint32 { return &v }
+	advA := &blelistener.Advertisement{ServiceData: blelistener.ServiceData{"a": {0x01, 0x02}}}
+	advB := &blelistener.Advertisement{ServiceData: blelistener.ServiceData{"a": {0x01, 0x03}}}
+	cases := []struct {
+		name      string
+		adv       *blelistener.Advertisement
+		m         *api.Measurement
+		after     time.Duration
+		duplicate bool
+	}{
+		{"first seq", advA, &api.Measurement{SensorMac: "s1", MeasurementSequenceNumber: seq(1)}, 0, false},
+		{"repeated seq", advB, &api.Measurement{SensorMac: "s1", MeasurementSequenceNumber: seq(1)}, 0, true},
+		{"other sensor", advA, &api.Measurement{SensorMac: "s2", MeasurementSequenceNumber: seq(1)}, 0, false},
+		{"next seq", advA, &api.Measurement{SensorMac: "s1", MeasurementSequenceNumber: seq(2)}, 0, false},
+		{"first payload", advA, &api.Measurement{SensorMac: "s3"}, 0, false},
+		{"repeated payload", advA, &api.Measurement{SensorMac: "s3"}, time.Second, true},
+		{"new payload", advB, &api.Measurement{SensorMac: "s3"}, 0, false},
+		{"old payload again", advA, &api.Measurement{SensorMac: "s3"}, 0, false},
+		{"repeated payload within window", advA, &api.Measurement{SensorMac: "s3"}, PayloadDuplicateWindow - time.Second, true},
+		{"repeated payload after window", advA, &api.Measurement{SensorMac: "s3"}, time.Second, false},
+		{"repeated payload after republish", advA, &api.Measurement{SensorMac: "s3"}, time.Second, true},
+		{"repeated seq after window", advA, &api.Measurement{SensorMac: "s1", MeasurementSequenceNumber: seq(2)}, 2 * PayloadDuplicateWindow, true},
+	}
+	now := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+	d := NewDeduplicator()
+	d.now = func() time.Time { return now }
+	for _, c := range cases {
+		now = now.Add(c.after)
+		if got := d.IsDuplicate(c.adv, c.m); got != c.duplicate {
+			t.Errorf("%s: got duplicate %t, expected %t", c.name, got, c.duplicate)
+		}
+	}
+}
+
+func TestRegistryMonitorPatterns(t *testing.T) {
+	registry := NewRegistry(NewRuuviDecoder(&config.RuuviDecoder{}), NewATCDecoder())
+	patterns, err := registry.MonitorPatterns()
+	if err != nil {
+		t.Fatalf("Failed to get monitor patterns: %v", err)
+	}
+	expected := []blelistener.MonitorPattern{
+		blelistener.ManufacturerDataPattern(ruuviManufacturerID),
+		{ADType: 0x16, Content: []byte{0x1a, 0x18}},
+	}
+	if diff := cmp.Diff(patterns, expected); diff != "" {
+		t.Errorf("unexpected difference:\n%v", diff)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decoders
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/blelistener"
+)
+
+// measurementID identifies a single measurement of a sensor. Sensors repeat
+// the same measurement in multiple advertisements, and BlueZ reports the same
+// advertisement again whenever e.g. RSSI changes.
+type measurementID struct {
+	hasSequenceNumber bool
+	sequenceNumber    uint32
+	payloadHash       uint64
+}
+
+type lastMeasurement struct {
+	id   measurementID
+	seen time.Time
+}
+
+// PayloadDuplicateWindow is how long an identical payload from a sensor without
+// a sequence number is considered a duplicate. After it passes, the payload is
+// treated as a new measurement, so values of sensors that don't change are
+// still published.
+const PayloadDuplicateWindow = 5 * time.Second
+
+// Deduplicator drops repeated measurements of the same sensor.
+//
+// Measurements are identified by the MeasurementSequenceNumber when decoder
+// provides it, and by the hash of the advertisement payload otherwise. The
+// payload hash matches only within PayloadDuplicateWindow. They are tracked
+// only per sensor, so the same measurement received by multiple adapters is
+// also a duplicate.
+type Deduplicator struct {
+	now      func() time.Time
+	lastSeen map[string]lastMeasurement
+}
+
+// IsDuplicate returns true if the measurement decoded from the advertisement
+// is the same as the last one accepted from that sensor. Otherwise, it
+// remembers the measurement and returns false.
+func (d *Deduplicator) IsDuplicate(adv *blelistener.Advertisement, m *api.Measurement) bool {
+	var id measurementID
+	if m.MeasurementSequenceNumber != nil {
+		id.hasSequenceNumber = true
+		id.sequenceNumber = *m.MeasurementSequenceNumber
+	} else {
+		id.payloadHash = payloadHash(adv)
+	}
+	now := d.now()
+	if last, ok := d.lastSeen[m.SensorMac]; ok && last.id == id {
+		if id.hasSequenceNumber || now.Sub(last.seen) < PayloadDuplicateWindow {
+			return true
+		}
+	}
+	d.lastSeen[m.SensorMac] = lastMeasurement{id: id, seen: now}
+	return false
+}
+
+// payloadHash returns hash of manufacturer and service data in the
+// advertisement, properties that don't carry sensor data are ignored.
+func payloadHash(adv *blelistener.Advertisement) uint64 {
+	h := fnv.New64a()
+	var buf [4]byte
+
+	ids := make([]int, 0, len(adv.ManufacturerData))
+	for id := range adv.ManufacturerData {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		data := adv.ManufacturerData[uint16(id)]
+		binary.BigEndian.PutUint16(buf[:2], uint16(id))
+		binary.BigEndian.PutUint16(buf[2:], uint16(len(data)))
+		h.Write(buf[:])
+		h.Write(data)
+	}
+
+	uuids := make([]string, 0, len(adv.ServiceData))
+	for uuid := range adv.ServiceData {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+	for _, uuid := range uuids {
+		data := adv.ServiceData[uuid]
+		h.Write([]byte(uuid))
+		binary.BigEndian.PutUint16(buf[:2], uint16(len(data)))
+		h.Write(buf[:2])
+		h.Write(data)
+	}
+	return h.Sum64()
+}
+
+// NewDeduplicator creates new Deduplicator.
+func NewDeduplicator() *Deduplicator {
+	return &Deduplicator{now: time.Now, lastSeen: make(map[string]lastMeasurement)}
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decoders
+
+import (
+	"fmt"
+
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/blelistener"
+	"github.com/p2004a/gbcsdpd/pkg/config"
+	"github.com/p2004a/gbcsdpd/pkg/ruuviparse"
+)
+
+const (
+	ruuviManufacturerID = 0x0499
+)
+
+// RuuviDecoder decodes RuuviTag advertisements.
+type RuuviDecoder struct {
+	filter         Filter
+	encryptionKeys map[string][]byte
+}
+
+// Name implements Decoder.
+func (d *RuuviDecoder) Name() string {
+	return "ruuvi"
+}
+
+// Filter implements Decoder.
+func (d *RuuviDecoder) Filter() *Filter {
+	return &d.filter
+}
+
+// Decode implements Decoder.
+func (d *RuuviDecoder) Decode(adv *blelistener.Advertisement) (*api.Measurement, error) {
+	key := d.encryptionKeys[adv.Address.String()]
+	ruuviData, err := ruuviparse.ParseWithKey(adv.ManufacturerData[ruuviManufacturerID], key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ruuvi data: %v", err)
+	}
+	m := newMeasurement(adv)
+	m.Temperature = nilToNaN(ruuviData.Temperature)
+	m.Humidity = nilToNaN(ruuviData.Humidity)
+	m.Pressure = nilToNaN(ruuviData.Pressure)
+	m.BatteryVoltage = nilToNaN(ruuviData.BatteryVoltage)
+	if ruuviData.TxPower != nil {
+		m.TxPower = *ruuviData.TxPower
+	}
+	m.MeasurementSequenceNumber = uintToUint32(ruuviData.MeasurementSeqNum)
+	m.AccelerationX = nilToNaN(ruuviData.Acceleration[0])
+	m.AccelerationY = nilToNaN(ruuviData.Acceleration[1])
+	m.AccelerationZ = nilToNaN(ruuviData.Acceleration[2])
+	m.MovementCounter = uintToUint32(ruuviData.MovementCounter)
+	m.Illuminance = nilToNaN(ruuviData.Luminosity)
+	m.Co2 = nilToNaN(ruuviData.CO2)
+	m.Pm1 = nilToNaN(ruuviData.PM1_0)
+	m.Pm2_5 = nilToNaN(ruuviData.PM2_5)
+	m.Pm4 = nilToNaN(ruuviData.PM4_0)
+	m.Pm10 = nilToNaN(ruuviData.PM10)
+	m.VocIndex = nilToNaN(ruuviData.VOCIndex)
+	m.NoxIndex = nilToNaN(ruuviData.NOxIndex)
+	return m, nil
+}
+
+// NewRuuviDecoder creates new RuuviDecoder.
+func NewRuuviDecoder(config *config.RuuviDecoder) *RuuviDecoder {
+	return &RuuviDecoder{
+		filter:         Filter{ManufacturerIDs: []uint16{ruuviManufacturerID}},
+		encryptionKeys: config.EncryptionKeys,
+	}
+}
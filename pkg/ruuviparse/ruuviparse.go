@@ -16,8 +16,10 @@ package ruuviparse
 
 import (
 	"bytes"
+	"crypto/aes"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"net"
 )
 
@@ -29,25 +31,44 @@ const (
 	UNSPECIFIED RuuviDataFormat = iota
 	RAWv1
 	RAWv2
+	DataFormat6
+	ExtendedV1
+	Encrypted
 )
 
 // RuuviData contains a parsed data from Ruuvi sensor.
 type RuuviData struct {
-	DataFormat        RuuviDataFormat
-	Temperature       *float32         // C
-	Humidity          *float32         // RH %
-	Pressure          *float32         // hPa
-	Acceleration      [3]*float32      // G
-	BatteryVoltage    *float32         // V
-	TxPower           *float32         // dBm
-	MovementCounter   *uint            // counter
-	MeasurementSeqNum *uint            // counter
-	Mac               net.HardwareAddr // MAC
+	DataFormat            RuuviDataFormat
+	Temperature           *float32         // C
+	Humidity              *float32         // RH %
+	Pressure              *float32         // hPa
+	Acceleration          [3]*float32      // G
+	BatteryVoltage        *float32         // V
+	TxPower               *float32         // dBm
+	MovementCounter       *uint            // counter
+	MeasurementSeqNum     *uint            // counter
+	PM1_0                 *float32         // µg/m³
+	PM2_5                 *float32         // µg/m³
+	PM4_0                 *float32         // µg/m³
+	PM10                  *float32         // µg/m³
+	CO2                   *float32         // ppm
+	VOCIndex              *float32         // index
+	NOxIndex              *float32         // index
+	Luminosity            *float32         // lx
+	CalibrationInProgress bool             // air quality sensor calibration
+	Mac                   net.HardwareAddr // MAC, only last 3 bytes in format 6
 }
 
 // Parse takes Manufacturer Specific Data field from BLE advertisement for the Ruuvi
 // manufacturer and parses the content into RuuviData.
-func Parse(data []byte) (rd *RuuviData, err error) {
+func Parse(data []byte) (*RuuviData, error) {
+	return ParseWithKey(data, nil)
+}
+
+// ParseWithKey works like Parse, but additionally supports the encrypted data
+// format 8 using the given 16 bytes AES key. Key can be nil if the tag doesn't
+// use encryption.
+func ParseWithKey(data []byte, key []byte) (rd *RuuviData, err error) {
 	if len(data) < 1 {
 		return nil, fmt.Errorf("got empty byte slice")
 	}
@@ -57,14 +78,28 @@ func Parse(data []byte) (rd *RuuviData, err error) {
 		if err != nil {
 			err = fmt.Errorf("failed to parse data in RAWv1 format: %v", err)
 		}
-		return parseRAWv1(data)
 	case 5:
 		rd, err = parseRAWv2(data)
 		if err != nil {
 			err = fmt.Errorf("failed to parse data in RAWv2 format: %v", err)
 		}
+	case 6:
+		rd, err = parseDataFormat6(data)
+		if err != nil {
+			err = fmt.Errorf("failed to parse data in format 6: %v", err)
+		}
+	case 8:
+		rd, err = parseEncrypted(data, key)
+		if err != nil {
+			err = fmt.Errorf("failed to parse data in format 8: %v", err)
+		}
+	case 0xE1:
+		rd, err = parseExtendedV1(data)
+		if err != nil {
+			err = fmt.Errorf("failed to parse data in E1 format: %v", err)
+		}
 	default:
-		err = fmt.Errorf("only RAWv1 and RAWv2 Ruuvi formats supported, got: %d", data[0])
+		err = fmt.Errorf("only RAWv1, RAWv2, 6, 8 and E1 Ruuvi formats supported, got: %d", data[0])
 	}
 	return
 }
@@ -183,3 +218,221 @@ func parseRAWv2(data []byte) (*RuuviData, error) {
 		Mac:               mac,
 	}, nil
 }
+
+const (
+	airFlagCalibrationInProgress = 0x01
+	airFlagVOCLSB                = 0x40
+	airFlagNOxLSB                = 0x80
+)
+
+// VOC and NOx indexes are 9-bit values with the least significant bit stored
+// in the flags byte.
+func airIndex(msb uint8, flags uint8, lsbFlag uint8) int {
+	v := int(msb) << 1
+	if flags&lsbFlag != 0 {
+		v |= 1
+	}
+	return v
+}
+
+func uint24(b [3]byte) int {
+	return int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+}
+
+// https://github.com/ruuvi/ruuvi-sensor-protocols/blob/master/dataformat_06.md
+func parseDataFormat6(data []byte) (*RuuviData, error) {
+	rd := struct {
+		DataFormat        uint8
+		Temperature       int16
+		Humidity          uint16
+		Pressure          uint16
+		PM2_5             uint16
+		CO2               uint16
+		VOC               uint8
+		NOx               uint8
+		Luminosity        uint8
+		Reserved          uint8
+		MeasurementSeqNum uint8
+		Flags             uint8
+		Mac               [3]byte
+	}{}
+	dataExpectedSize := binary.Size(rd)
+	if len(data) != dataExpectedSize {
+		return nil, fmt.Errorf("Ruuvi manufacturer data must be exactly %d bytes", dataExpectedSize)
+	}
+
+	buffer := bytes.NewBuffer(data)
+	err := binary.Read(buffer, binary.BigEndian, &rd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal struct: %v", err)
+	}
+
+	mac := rd.Mac[:]
+	if rd.Mac == [3]byte{0xff, 0xff, 0xff} {
+		mac = nil
+	}
+
+	voc := airIndex(rd.VOC, rd.Flags, airFlagVOCLSB)
+	nox := airIndex(rd.NOx, rd.Flags, airFlagNOxLSB)
+	// Luminosity is encoded logarithmically on 0-254 range mapping to 0-65535 lx.
+	luminosity := float32(math.Exp(float64(rd.Luminosity)*math.Log(65536)/254.0) - 1.0)
+
+	return &RuuviData{
+		DataFormat:            DataFormat6,
+		Temperature:           nilInvF32(-32768, int(rd.Temperature), float32(rd.Temperature)*0.005),
+		Humidity:              nilInvF32(65535, int(rd.Humidity), float32(rd.Humidity)*0.0025),
+		Pressure:              nilInvF32(65535, int(rd.Pressure), (float32(rd.Pressure)+50000.0)/100.0),
+		PM2_5:                 nilInvF32(65535, int(rd.PM2_5), float32(rd.PM2_5)*0.1),
+		CO2:                   nilInvF32(65535, int(rd.CO2), float32(rd.CO2)),
+		VOCIndex:              nilInvF32(511, voc, float32(voc)),
+		NOxIndex:              nilInvF32(511, nox, float32(nox)),
+		Luminosity:            nilInvF32(255, int(rd.Luminosity), luminosity),
+		MeasurementSeqNum:     nilUint(uint(rd.MeasurementSeqNum)),
+		CalibrationInProgress: rd.Flags&airFlagCalibrationInProgress != 0,
+		Mac:                   mac,
+	}, nil
+}
+
+// https://github.com/ruuvi/ruuvi-sensor-protocols/blob/master/dataformat_e1.md
+func parseExtendedV1(data []byte) (*RuuviData, error) {
+	rd := struct {
+		DataFormat        uint8
+		Temperature       int16
+		Humidity          uint16
+		Pressure          uint16
+		PM1_0             uint16
+		PM2_5             uint16
+		PM4_0             uint16
+		PM10              uint16
+		CO2               uint16
+		VOC               uint8
+		NOx               uint8
+		Luminosity        [3]byte
+		Reserved1         [3]byte
+		MeasurementSeqNum [3]byte
+		Flags             uint8
+		Reserved2         [5]byte
+		Mac               [6]byte
+	}{}
+	dataExpectedSize := binary.Size(rd)
+	if len(data) != dataExpectedSize {
+		return nil, fmt.Errorf("Ruuvi manufacturer data must be exactly %d bytes", dataExpectedSize)
+	}
+
+	buffer := bytes.NewBuffer(data)
+	err := binary.Read(buffer, binary.BigEndian, &rd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal struct: %v", err)
+	}
+
+	mac := rd.Mac[:]
+	if rd.Mac == [6]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff} {
+		mac = nil
+	}
+
+	voc := airIndex(rd.VOC, rd.Flags, airFlagVOCLSB)
+	nox := airIndex(rd.NOx, rd.Flags, airFlagNOxLSB)
+	luminosity := uint24(rd.Luminosity)
+	seqNum := uint24(rd.MeasurementSeqNum)
+
+	return &RuuviData{
+		DataFormat:            ExtendedV1,
+		Temperature:           nilInvF32(-32768, int(rd.Temperature), float32(rd.Temperature)*0.005),
+		Humidity:              nilInvF32(65535, int(rd.Humidity), float32(rd.Humidity)*0.0025),
+		Pressure:              nilInvF32(65535, int(rd.Pressure), (float32(rd.Pressure)+50000.0)/100.0),
+		PM1_0:                 nilInvF32(65535, int(rd.PM1_0), float32(rd.PM1_0)*0.1),
+		PM2_5:                 nilInvF32(65535, int(rd.PM2_5), float32(rd.PM2_5)*0.1),
+		PM4_0:                 nilInvF32(65535, int(rd.PM4_0), float32(rd.PM4_0)*0.1),
+		PM10:                  nilInvF32(65535, int(rd.PM10), float32(rd.PM10)*0.1),
+		CO2:                   nilInvF32(65535, int(rd.CO2), float32(rd.CO2)),
+		VOCIndex:              nilInvF32(511, voc, float32(voc)),
+		NOxIndex:              nilInvF32(511, nox, float32(nox)),
+		Luminosity:            nilInvF32(0xFFFFFF, luminosity, float32(luminosity)*0.01),
+		MeasurementSeqNum:     nilInvUint(0xFFFFFF, seqNum, uint(seqNum)),
+		CalibrationInProgress: rd.Flags&airFlagCalibrationInProgress != 0,
+		Mac:                   mac,
+	}, nil
+}
+
+// CRC-8 with polynomial 0x07 and zero initial value.
+func crc8(data []byte) uint8 {
+	var crc uint8
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// https://github.com/ruuvi/ruuvi-sensor-protocols/blob/master/dataformat_08.md
+func parseEncrypted(data []byte, key []byte) (*RuuviData, error) {
+	rd := struct {
+		DataFormat    uint8
+		EncryptedData [16]byte
+		CRC           uint8
+		Mac           [6]byte
+	}{}
+	dataExpectedSize := binary.Size(rd)
+	if len(data) != dataExpectedSize {
+		return nil, fmt.Errorf("Ruuvi manufacturer data must be exactly %d bytes", dataExpectedSize)
+	}
+	if key == nil {
+		return nil, fmt.Errorf("data is encrypted but there is no key")
+	}
+
+	buffer := bytes.NewBuffer(data)
+	err := binary.Read(buffer, binary.BigEndian, &rd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal struct: %v", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %v", err)
+	}
+	// Payload is a single AES block encrypted in ECB mode.
+	var plaintext [16]byte
+	block.Decrypt(plaintext[:], rd.EncryptedData[:])
+	if crc := crc8(plaintext[:]); crc != rd.CRC {
+		return nil, fmt.Errorf("CRC mismatch after decryption, wrong key? got 0x%02x, expected 0x%02x", crc, rd.CRC)
+	}
+
+	pd := struct {
+		Temperature       int16
+		Humidity          uint16
+		Pressure          uint16
+		PowerInfo         uint16
+		MovementCounter   uint8
+		MeasurementSeqNum uint16
+		Reserved          [5]byte
+	}{}
+	if err := binary.Read(bytes.NewBuffer(plaintext[:]), binary.BigEndian, &pd); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal decrypted struct: %v", err)
+	}
+
+	mac := rd.Mac[:]
+	if rd.Mac == [6]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff} {
+		mac = nil
+	}
+
+	battery := pd.PowerInfo >> 5
+	txPower := pd.PowerInfo & 0x001F
+
+	return &RuuviData{
+		DataFormat:        Encrypted,
+		Temperature:       nilInvF32(-32768, int(pd.Temperature), float32(pd.Temperature)*0.005),
+		Humidity:          nilInvF32(65535, int(pd.Humidity), float32(pd.Humidity)*0.0025),
+		Pressure:          nilInvF32(65535, int(pd.Pressure), (float32(pd.Pressure)+50000.0)/100.0),
+		BatteryVoltage:    nilInvF32(2047, int(battery), (float32(battery)+1600.0)/1000.0),
+		TxPower:           nilInvF32(31, int(txPower), float32(txPower)*2.0-40.0),
+		MovementCounter:   nilInvUint(255, int(pd.MovementCounter), uint(pd.MovementCounter)),
+		MeasurementSeqNum: nilInvUint(65535, int(pd.MeasurementSeqNum), uint(pd.MeasurementSeqNum)),
+		Mac:               mac,
+	}, nil
+}
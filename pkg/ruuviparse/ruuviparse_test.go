@@ -120,6 +120,172 @@ func TestParsingValid(t *testing.T) {
 			DataFormat:   RAWv2,
 			Acceleration: [3]*float32{},
 		}},
+		{"format 6 valid", toB("06170C5668C79E007000C90501D9FFCD004C884F"), &RuuviData{
+			DataFormat:        DataFormat6,
+			Temperature:       f32Ptr(29.5),
+			Pressure:          f32Ptr(1011.02),
+			Humidity:          f32Ptr(55.3),
+			Acceleration:      [3]*float32{},
+			PM2_5:             f32Ptr(11.2),
+			CO2:               f32Ptr(201),
+			VOCIndex:          f32Ptr(10),
+			NOxIndex:          f32Ptr(2),
+			Luminosity:        f32Ptr(13026.669),
+			MeasurementSeqNum: uintPtr(205),
+			Mac:               []byte{0x4C, 0x88, 0x4F},
+		}},
+		{"format 6 VOC LSB", toB("06170C5668C79E007000C90501D9FFCD404C884F"), &RuuviData{
+			DataFormat:        DataFormat6,
+			Temperature:       f32Ptr(29.5),
+			Pressure:          f32Ptr(1011.02),
+			Humidity:          f32Ptr(55.3),
+			Acceleration:      [3]*float32{},
+			PM2_5:             f32Ptr(11.2),
+			CO2:               f32Ptr(201),
+			VOCIndex:          f32Ptr(11),
+			NOxIndex:          f32Ptr(2),
+			Luminosity:        f32Ptr(13026.669),
+			MeasurementSeqNum: uintPtr(205),
+			Mac:               []byte{0x4C, 0x88, 0x4F},
+		}},
+		{"format 6 NOx LSB", toB("06170C5668C79E007000C90501D9FFCD804C884F"), &RuuviData{
+			DataFormat:        DataFormat6,
+			Temperature:       f32Ptr(29.5),
+			Pressure:          f32Ptr(1011.02),
+			Humidity:          f32Ptr(55.3),
+			Acceleration:      [3]*float32{},
+			PM2_5:             f32Ptr(11.2),
+			CO2:               f32Ptr(201),
+			VOCIndex:          f32Ptr(10),
+			NOxIndex:          f32Ptr(3),
+			Luminosity:        f32Ptr(13026.669),
+			MeasurementSeqNum: uintPtr(205),
+			Mac:               []byte{0x4C, 0x88, 0x4F},
+		}},
+		{"format 6 maximum", toB("067FFF9C40FFFE27109C40FAFAFEFFFF004C884F"), &RuuviData{
+			DataFormat:        DataFormat6,
+			Temperature:       f32Ptr(163.835),
+			Pressure:          f32Ptr(1155.34),
+			Humidity:          f32Ptr(100.0),
+			Acceleration:      [3]*float32{},
+			PM2_5:             f32Ptr(1000.0),
+			CO2:               f32Ptr(40000),
+			VOCIndex:          f32Ptr(500),
+			NOxIndex:          f32Ptr(500),
+			Luminosity:        f32Ptr(65535),
+			MeasurementSeqNum: uintPtr(255),
+			Mac:               []byte{0x4C, 0x88, 0x4F},
+		}},
+		{"format 6 minimum", toB("0680010000000000000000000000FF00004C884F"), &RuuviData{
+			DataFormat:        DataFormat6,
+			Temperature:       f32Ptr(-163.835),
+			Pressure:          f32Ptr(500.00),
+			Humidity:          f32Ptr(0.0),
+			Acceleration:      [3]*float32{},
+			PM2_5:             f32Ptr(0.0),
+			CO2:               f32Ptr(0),
+			VOCIndex:          f32Ptr(0),
+			NOxIndex:          f32Ptr(0),
+			Luminosity:        f32Ptr(0),
+			MeasurementSeqNum: uintPtr(0),
+			Mac:               []byte{0x4C, 0x88, 0x4F},
+		}},
+		{"format 6 invalid", toB("068000FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF"), &RuuviData{
+			DataFormat:            DataFormat6,
+			Acceleration:          [3]*float32{},
+			MeasurementSeqNum:     uintPtr(255),
+			CalibrationInProgress: true,
+		}},
+		{"E1 valid", toB("E1170C5668C79E0065007004BD11CA00C90A0213E0ACFFFFFFDECDEE00FFFFFFFFFFCBB8334C884F"), &RuuviData{
+			DataFormat:        ExtendedV1,
+			Temperature:       f32Ptr(29.5),
+			Pressure:          f32Ptr(1011.02),
+			Humidity:          f32Ptr(55.3),
+			Acceleration:      [3]*float32{},
+			PM1_0:             f32Ptr(10.1),
+			PM2_5:             f32Ptr(11.2),
+			PM4_0:             f32Ptr(121.3),
+			PM10:              f32Ptr(455.4),
+			CO2:               f32Ptr(201),
+			VOCIndex:          f32Ptr(20),
+			NOxIndex:          f32Ptr(4),
+			Luminosity:        f32Ptr(13027.0),
+			MeasurementSeqNum: uintPtr(14601710),
+			Mac:               []byte{0xCB, 0xB8, 0x33, 0x4C, 0x88, 0x4F},
+		}},
+		{"E1 VOC LSB", toB("E1170C5668C79E0065007004BD11CA00C90A0213E0ACFFFFFFDECDEE40FFFFFFFFFFCBB8334C884F"), &RuuviData{
+			DataFormat:        ExtendedV1,
+			Temperature:       f32Ptr(29.5),
+			Pressure:          f32Ptr(1011.02),
+			Humidity:          f32Ptr(55.3),
+			Acceleration:      [3]*float32{},
+			PM1_0:             f32Ptr(10.1),
+			PM2_5:             f32Ptr(11.2),
+			PM4_0:             f32Ptr(121.3),
+			PM10:              f32Ptr(455.4),
+			CO2:               f32Ptr(201),
+			VOCIndex:          f32Ptr(21),
+			NOxIndex:          f32Ptr(4),
+			Luminosity:        f32Ptr(13027.0),
+			MeasurementSeqNum: uintPtr(14601710),
+			Mac:               []byte{0xCB, 0xB8, 0x33, 0x4C, 0x88, 0x4F},
+		}},
+		{"E1 NOx LSB", toB("E1170C5668C79E0065007004BD11CA00C90A0213E0ACFFFFFFDECDEE80FFFFFFFFFFCBB8334C884F"), &RuuviData{
+			DataFormat:        ExtendedV1,
+			Temperature:       f32Ptr(29.5),
+			Pressure:          f32Ptr(1011.02),
+			Humidity:          f32Ptr(55.3),
+			Acceleration:      [3]*float32{},
+			PM1_0:             f32Ptr(10.1),
+			PM2_5:             f32Ptr(11.2),
+			PM4_0:             f32Ptr(121.3),
+			PM10:              f32Ptr(455.4),
+			CO2:               f32Ptr(201),
+			VOCIndex:          f32Ptr(20),
+			NOxIndex:          f32Ptr(5),
+			Luminosity:        f32Ptr(13027.0),
+			MeasurementSeqNum: uintPtr(14601710),
+			Mac:               []byte{0xCB, 0xB8, 0x33, 0x4C, 0x88, 0x4F},
+		}},
+		{"E1 maximum", toB("E17FFF9C40FFFE27102710271027109C40FAFADC28F0FFFFFFFFFFFE00FFFFFFFFFFCBB8334C884F"), &RuuviData{
+			DataFormat:        ExtendedV1,
+			Temperature:       f32Ptr(163.835),
+			Pressure:          f32Ptr(1155.34),
+			Humidity:          f32Ptr(100.0),
+			Acceleration:      [3]*float32{},
+			PM1_0:             f32Ptr(1000.0),
+			PM2_5:             f32Ptr(1000.0),
+			PM4_0:             f32Ptr(1000.0),
+			PM10:              f32Ptr(1000.0),
+			CO2:               f32Ptr(40000),
+			VOCIndex:          f32Ptr(500),
+			NOxIndex:          f32Ptr(500),
+			Luminosity:        f32Ptr(144284.0),
+			MeasurementSeqNum: uintPtr(16777214),
+			Mac:               []byte{0xCB, 0xB8, 0x33, 0x4C, 0x88, 0x4F},
+		}},
+		{"E1 minimum", toB("E1800100000000000000000000000000000000000000FFFFFF00000000FFFFFFFFFFCBB8334C884F"), &RuuviData{
+			DataFormat:        ExtendedV1,
+			Temperature:       f32Ptr(-163.835),
+			Pressure:          f32Ptr(500.00),
+			Humidity:          f32Ptr(0.0),
+			Acceleration:      [3]*float32{},
+			PM1_0:             f32Ptr(0.0),
+			PM2_5:             f32Ptr(0.0),
+			PM4_0:             f32Ptr(0.0),
+			PM10:              f32Ptr(0.0),
+			CO2:               f32Ptr(0),
+			VOCIndex:          f32Ptr(0),
+			NOxIndex:          f32Ptr(0),
+			Luminosity:        f32Ptr(0),
+			MeasurementSeqNum: uintPtr(0),
+			Mac:               []byte{0xCB, 0xB8, 0x33, 0x4C, 0x88, 0x4F},
+		}},
+		{"E1 invalid", toB("E18000FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF"), &RuuviData{
+			DataFormat:            ExtendedV1,
+			Acceleration:          [3]*float32{},
+			CalibrationInProgress: true,
+		}},
 		{"RAWv1 valid", toB("03291A1ECE1EFC18F94202CA0B53"), &RuuviData{
 			DataFormat:     RAWv1,
 			Temperature:    f32Ptr(26.3),
@@ -162,6 +328,20 @@ func TestParsingValid(t *testing.T) {
 			assertF32Eq(t, "battery voltage", res.BatteryVoltage, tc.expected.BatteryVoltage)
 			assertUintEq(t, "movement counted", res.MovementCounter, tc.expected.MovementCounter)
 			assertUintEq(t, "measurement sequence number", res.MeasurementSeqNum, tc.expected.MeasurementSeqNum)
+			assertF32Eq(t, "pm1.0", res.PM1_0, tc.expected.PM1_0)
+			assertF32Eq(t, "pm2.5", res.PM2_5, tc.expected.PM2_5)
+			assertF32Eq(t, "pm4.0", res.PM4_0, tc.expected.PM4_0)
+			assertF32Eq(t, "pm10", res.PM10, tc.expected.PM10)
+			assertF32Eq(t, "co2", res.CO2, tc.expected.CO2)
+			assertF32Eq(t, "voc index", res.VOCIndex, tc.expected.VOCIndex)
+			assertF32Eq(t, "nox index", res.NOxIndex, tc.expected.NOxIndex)
+			assertF32Eq(t, "luminosity", res.Luminosity, tc.expected.Luminosity)
+			if res.CalibrationInProgress != tc.expected.CalibrationInProgress {
+				t.Errorf("calibration in progress not equal: value %v, expected %v", res.CalibrationInProgress, tc.expected.CalibrationInProgress)
+			}
+			if res.DataFormat != tc.expected.DataFormat {
+				t.Errorf("data format not equal: value %d, expected %d", res.DataFormat, tc.expected.DataFormat)
+			}
 			if res.Mac.String() != tc.expected.Mac.String() {
 				t.Errorf("MAC not equal: value %s, expected %s", macToStr(res.Mac), macToStr(tc.expected.Mac))
 			}
@@ -176,6 +356,8 @@ func TestParsingInvalid(t *testing.T) {
 	}{
 		{"empty", []byte{}},
 		{"unsupported format", toB("537FFF")},
+		{"format 6 too short", toB("06170C5668C79E007000C90501D9FFCD004C88")},
+		{"E1 too short", toB("E1170C5668C79E0065007004BD11CA00C90A0213E0ACFFFFFFDECDEE00FFFFFFFFFFCBB8334C88")},
 	}
 
 	for _, tc := range cases {
@@ -187,3 +369,45 @@ func TestParsingInvalid(t *testing.T) {
 		})
 	}
 }
+
+func TestParsingEncrypted(t *testing.T) {
+	key := toB("000102030405060708090A0B0C0D0E0F")
+	data := toB("0826E0D694BF0F7A97987130871C0755595BCBB8334C884F")
+
+	res, err := ParseWithKey(data, key)
+	if err != nil {
+		t.Fatalf("couldn't parse data: %v", err)
+	}
+	if res.DataFormat != Encrypted {
+		t.Errorf("data format not equal: value %d, expected %d", res.DataFormat, Encrypted)
+	}
+	assertF32Eq(t, "temperature", res.Temperature, f32Ptr(24.3))
+	assertF32Eq(t, "humidity", res.Humidity, f32Ptr(53.49))
+	assertF32Eq(t, "pressure", res.Pressure, f32Ptr(1000.44))
+	assertF32Eq(t, "tx power", res.TxPower, f32Ptr(4.0))
+	assertF32Eq(t, "battery voltage", res.BatteryVoltage, f32Ptr(2.977))
+	assertUintEq(t, "movement counted", res.MovementCounter, uintPtr(66))
+	assertUintEq(t, "measurement sequence number", res.MeasurementSeqNum, uintPtr(205))
+	if res.Mac.String() != "cb:b8:33:4c:88:4f" {
+		t.Errorf("MAC not equal: value %s, expected %s", macToStr(res.Mac), "cb:b8:33:4c:88:4f")
+	}
+
+	if _, err := Parse(data); err == nil {
+		t.Errorf("Expected error when parsing without key, got success")
+	}
+	if _, err := ParseWithKey(data, toB("0F0E0D0C0B0A09080706050403020100")); err == nil {
+		t.Errorf("Expected error when parsing with wrong key, got success")
+	}
+	if _, err := ParseWithKey(toB("0826E0D694BF0F7A97987130871C0755595ACBB8334C884F"), key); err == nil {
+		t.Errorf("Expected error when parsing with wrong CRC, got success")
+	}
+	if _, err := ParseWithKey(toB("0826E0D694BF0F7A97987130871C0755585BCBB8334C884F"), key); err == nil {
+		t.Errorf("Expected error when parsing corrupted data, got success")
+	}
+}
+
+func TestCRC8(t *testing.T) {
+	if crc := crc8([]byte("123456789")); crc != 0xF4 {
+		t.Errorf("CRC8 check value not equal: value 0x%02x, expected 0xf4", crc)
+	}
+}
@@ -33,6 +33,7 @@ type CloudPubSubSink struct {
 	client *pubsub.Client
 	topic  *pubsub.Topic
 	rl     *rateLimiter
+	fw     *forwarder
 }
 
 // Publish is used to push measurement for publication.
@@ -41,9 +42,12 @@ func (s *CloudPubSubSink) Publish(m *api.Measurement) {
 }
 
 func (s *CloudPubSubSink) groupPublish(ms []*api.Measurement) {
+	s.fw.Publish(&api.MeasurementsPublication{Measurements: ms})
+}
+
+func (s *CloudPubSubSink) publish(pub *api.MeasurementsPublication) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Second)
 	defer cancel()
-	pub := &api.MeasurementsPublication{Measurements: ms}
 	serPub, err := proto.Marshal(pub)
 	if err != nil {
 		log.Fatalf("Failed to binary encode measurement: %v", err)
@@ -58,9 +62,7 @@ func (s *CloudPubSubSink) groupPublish(ms []*api.Measurement) {
 			"subFolder":              "v1",
 		},
 	}).Get(ctx)
-	if err != nil {
-		log.Printf("Failed to publish measurement: %v", err)
-	}
+	return err
 }
 
 // NewCloudPubSubSink creates new CloudPubSubSink.
@@ -77,6 +79,10 @@ func NewCloudPubSubSink(config *config.CloudPubSubSink) (*CloudPubSubSink, error
 		topic:  topic,
 		client: client,
 	}
+	s.fw, err = newForwarder(config.Queue, s.publish)
+	if err != nil {
+		return nil, err
+	}
 	s.rl = newRateLimiter(config.RateLimit, s.groupPublish)
 	return s, nil
 }
@@ -0,0 +1,291 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/backoff"
+	"github.com/p2004a/gbcsdpd/pkg/config"
+	"google.golang.org/protobuf/proto"
+)
+
+const diskQueueFileExt = ".pub"
+
+type diskQueueEntry struct {
+	seq     uint64
+	size    int64
+	created time.Time
+}
+
+// diskQueue is a persistent FIFO queue of publications. Every publication is
+// stored in a separate file in the directory, named by its sequence number.
+// When the total size of publications exceeds the limit, or they get too old,
+// the oldest ones are dropped.
+type diskQueue struct {
+	config  *config.Queue
+	entries []diskQueueEntry // The oldest first
+	size    int64
+	nextSeq uint64
+}
+
+func openDiskQueue(config *config.Queue) (*diskQueue, error) {
+	if err := os.MkdirAll(config.Directory, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %v", err)
+	}
+	files, err := os.ReadDir(config.Directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queue directory: %v", err)
+	}
+	q := &diskQueue{config: config}
+	for _, file := range files {
+		name := file.Name()
+		if strings.HasSuffix(name, ".tmp") {
+			// Leftover of interrupted push.
+			os.Remove(filepath.Join(config.Directory, name))
+			continue
+		}
+		if !strings.HasSuffix(name, diskQueueFileExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, diskQueueFileExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat queued publication: %v", err)
+		}
+		q.entries = append(q.entries, diskQueueEntry{seq, info.Size(), info.ModTime()})
+		q.size += info.Size()
+	}
+	sort.Slice(q.entries, func(i, j int) bool { return q.entries[i].seq < q.entries[j].seq })
+	if len(q.entries) > 0 {
+		q.nextSeq = q.entries[len(q.entries)-1].seq + 1
+	}
+	q.trim(time.Now())
+	return q, nil
+}
+
+func (q *diskQueue) path(seq uint64) string {
+	return filepath.Join(q.config.Directory, fmt.Sprintf("%020d%s", seq, diskQueueFileExt))
+}
+
+func (q *diskQueue) len() int {
+	return len(q.entries)
+}
+
+// push stores publication at the end of the queue.
+func (q *diskQueue) push(pub *api.MeasurementsPublication, now time.Time) error {
+	serPub, err := proto.Marshal(pub)
+	if err != nil {
+		return fmt.Errorf("failed to binary encode publication: %v", err)
+	}
+	seq := q.nextSeq
+	// Written to temporary file first, so partially written publications
+	// are never read.
+	tmpPath := q.path(seq) + ".tmp"
+	if err := os.WriteFile(tmpPath, serPub, 0600); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write publication: %v", err)
+	}
+	if err := os.Rename(tmpPath, q.path(seq)); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename publication file: %v", err)
+	}
+	q.nextSeq++
+	q.entries = append(q.entries, diskQueueEntry{seq, int64(len(serPub)), now})
+	q.size += int64(len(serPub))
+	q.trim(now)
+	return nil
+}
+
+// peek returns the oldest publication in the queue, or nil if it's empty.
+// Publications that can't be read are dropped.
+func (q *diskQueue) peek(now time.Time) (*api.MeasurementsPublication, error) {
+	q.trim(now)
+	if len(q.entries) == 0 {
+		return nil, nil
+	}
+	path := q.path(q.entries[0].seq)
+	serPub, err := os.ReadFile(path)
+	if err != nil {
+		q.pop()
+		return nil, fmt.Errorf("failed to read queued publication %s, dropping it: %v", path, err)
+	}
+	pub := &api.MeasurementsPublication{}
+	if err := proto.Unmarshal(serPub, pub); err != nil {
+		q.pop()
+		return nil, fmt.Errorf("failed to decode queued publication %s, dropping it: %v", path, err)
+	}
+	return pub, nil
+}
+
+// pop removes the oldest publication from the queue.
+func (q *diskQueue) pop() {
+	if len(q.entries) == 0 {
+		return
+	}
+	if err := os.Remove(q.path(q.entries[0].seq)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove queued publication: %v", err)
+	}
+	q.size -= q.entries[0].size
+	q.entries = q.entries[1:]
+}
+
+// trim drops the oldest publications over the size limit and too old ones.
+func (q *diskQueue) trim(now time.Time) {
+	dropped := 0
+	for len(q.entries) > 0 && (q.size > q.config.MaxSize || now.Sub(q.entries[0].created) > q.config.MaxAge) {
+		q.pop()
+		dropped++
+	}
+	if dropped > 0 {
+		log.Printf("Dropped %d queued publications from %s exceeding the queue limits", dropped, q.config.Directory)
+	}
+}
+
+// permanentError is returned by publish functions when retrying the
+// publication is pointless or harmful, e.g. the destination rejected it, or it
+// might still be delivered. The forwarder drops such publications.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// forwarder publishes publications, and when configured with a queue, it
+// stores them on disk while the destination is unavailable and replays them
+// in order once it recovers.
+type forwarder struct {
+	publish        func(*api.MeasurementsPublication) error
+	baseRetryDelay time.Duration
+	m              sync.Mutex // Guards queue
+	queue          *diskQueue // Nil when not configured
+	wake           chan struct{}
+}
+
+// Publish publishes the publication, or queues it when the destination is
+// unavailable or there are already queued publications.
+func (f *forwarder) Publish(pub *api.MeasurementsPublication) {
+	if f.queue == nil {
+		if err := f.publish(pub); err != nil {
+			log.Printf("Failed to publish measurement: %v", err)
+		}
+		return
+	}
+	f.m.Lock()
+	queued := f.queue.len() > 0
+	f.m.Unlock()
+	if !queued {
+		err := f.publish(pub)
+		if err == nil {
+			return
+		}
+		if isPermanent(err) {
+			log.Printf("Failed to publish measurement, dropping: %v", err)
+			return
+		}
+		log.Printf("Failed to publish measurement, queuing: %v", err)
+	}
+	f.m.Lock()
+	err := f.queue.push(pub, time.Now())
+	f.m.Unlock()
+	if err != nil {
+		log.Printf("Failed to queue publication: %v", err)
+		return
+	}
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// replay publishes queued publications in order, retrying with backoff until
+// the destination is available. Publications failing with permanentError are
+// dropped.
+func (f *forwarder) replay() {
+	retryNum := 0
+	for {
+		f.m.Lock()
+		pub, err := f.queue.peek(time.Now())
+		f.m.Unlock()
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+		if pub == nil {
+			retryNum = 0
+			<-f.wake
+			continue
+		}
+		if err := f.publish(pub); err != nil {
+			if !isPermanent(err) {
+				retryNum++
+				log.Printf("Failed to publish queued measurements, retrying: %v", err)
+				time.Sleep(backoff.Exponential(retryNum, f.baseRetryDelay, 5*time.Minute, 2.0))
+				continue
+			}
+			log.Printf("Failed to publish queued measurements, dropping: %v", err)
+		}
+		retryNum = 0
+		f.m.Lock()
+		f.queue.pop()
+		f.m.Unlock()
+	}
+}
+
+// newForwarder creates new forwarder, the queue config can be nil, then
+// publications are dropped when the destination is unavailable.
+func newForwarder(queueConfig *config.Queue, publish func(*api.MeasurementsPublication) error) (*forwarder, error) {
+	f := &forwarder{
+		publish:        publish,
+		baseRetryDelay: time.Second,
+		wake:           make(chan struct{}, 1),
+	}
+	if queueConfig == nil {
+		return f, nil
+	}
+	queue, err := openDiskQueue(queueConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open queue in %s: %v", queueConfig.Directory, err)
+	}
+	f.queue = queue
+	// Replay publications queued before restart.
+	f.wake <- struct{}{}
+	go f.replay()
+	return f, nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/config"
+)
+
+func testPublication(mac string) *api.MeasurementsPublication {
+	return &api.MeasurementsPublication{Measurements: []*api.Measurement{{SensorMac: mac}}}
+}
+
+func popAll(t *testing.T, q *diskQueue, now time.Time) []string {
+	var macs []string
+	for {
+		pub, err := q.peek(now)
+		if err != nil {
+			t.Fatalf("Failed to peek: %v", err)
+		}
+		if pub == nil {
+			return macs
+		}
+		macs = append(macs, pub.Measurements[0].SensorMac)
+		q.pop()
+	}
+}
+
+func TestDiskQueue(t *testing.T) {
+	conf := &config.Queue{Directory: t.TempDir(), MaxSize: 1024, MaxAge: time.Hour}
+	start := time.Now()
+	q, err := openDiskQueue(conf)
+	if err != nil {
+		t.Fatalf("Failed to open queue: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := q.push(testPublication(fmt.Sprintf("mac%d", i)), start); err != nil {
+			t.Fatalf("Failed to push: %v", err)
+		}
+	}
+
+	// Queued publications survive reopening.
+	q, err = openDiskQueue(conf)
+	if err != nil {
+		t.Fatalf("Failed to reopen queue: %v", err)
+	}
+	if q.len() != 3 {
+		t.Fatalf("Reopened queue has %d publications, expected 3", q.len())
+	}
+	if err := q.push(testPublication("mac3"), start); err != nil {
+		t.Fatalf("Failed to push: %v", err)
+	}
+	if macs := fmt.Sprint(popAll(t, q, start)); macs != "[mac0 mac1 mac2 mac3]" {
+		t.Errorf("Got publications %s, expected [mac0 mac1 mac2 mac3]", macs)
+	}
+
+	// The oldest publications are dropped when the size limit is exceeded.
+	conf.MaxSize = 3 * int64(len("\n\x06\n\x04mac0"))
+	for i := 0; i < 5; i++ {
+		if err := q.push(testPublication(fmt.Sprintf("mac%d", i)), start.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("Failed to push: %v", err)
+		}
+	}
+	// And the old ones when they exceed the max age.
+	if macs := fmt.Sprint(popAll(t, q, start.Add(time.Hour+3*time.Minute+time.Second))); macs != "[mac4]" {
+		t.Errorf("Got publications %s, expected [mac4]", macs)
+	}
+}
+
+// fakeDestination fails publishing while it's unavailable.
+type fakeDestination struct {
+	m         sync.Mutex
+	available bool
+	published []string
+	rejected  map[string]bool // Publications failing with permanentError
+	attempts  chan struct{}
+}
+
+func (d *fakeDestination) publish(pub *api.MeasurementsPublication) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+	select {
+	case d.attempts <- struct{}{}:
+	default:
+	}
+	if !d.available {
+		return fmt.Errorf("destination unavailable")
+	}
+	if d.rejected[pub.Measurements[0].SensorMac] {
+		return &permanentError{fmt.Errorf("publication rejected")}
+	}
+	d.published = append(d.published, pub.Measurements[0].SensorMac)
+	return nil
+}
+
+func (d *fakeDestination) setAvailable(available bool) {
+	d.m.Lock()
+	d.available = available
+	d.m.Unlock()
+}
+
+func (d *fakeDestination) waitForPublished(t *testing.T, count int) []string {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		d.m.Lock()
+		published := append([]string(nil), d.published...)
+		d.m.Unlock()
+		if len(published) >= count {
+			return published
+		}
+	}
+	t.Fatalf("Timed out waiting for %d publications", count)
+	return nil
+}
+
+func TestForwarder(t *testing.T) {
+	queue, err := openDiskQueue(&config.Queue{Directory: t.TempDir(), MaxSize: 1024 * 1024, MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to open queue: %v", err)
+	}
+	dest := &fakeDestination{attempts: make(chan struct{}, 1)}
+	f := &forwarder{
+		publish:        dest.publish,
+		baseRetryDelay: time.Millisecond,
+		queue:          queue,
+		wake:           make(chan struct{}, 1),
+	}
+	go f.replay()
+
+	f.Publish(testPublication("mac0"))
+	f.Publish(testPublication("mac1"))
+	// Make sure replay is retrying before the destination recovers.
+	<-dest.attempts
+	<-dest.attempts
+	dest.setAvailable(true)
+	f.Publish(testPublication("mac2"))
+
+	if published := fmt.Sprint(dest.waitForPublished(t, 3)); published != "[mac0 mac1 mac2]" {
+		t.Errorf("Published %s, expected [mac0 mac1 mac2]", published)
+	}
+}
+
+func TestForwarderDropsPermanentErrors(t *testing.T) {
+	queue, err := openDiskQueue(&config.Queue{Directory: t.TempDir(), MaxSize: 1024 * 1024, MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to open queue: %v", err)
+	}
+	dest := &fakeDestination{
+		rejected: map[string]bool{"bad0": true, "bad1": true},
+		attempts: make(chan struct{}, 1),
+	}
+	f := &forwarder{
+		publish:        dest.publish,
+		baseRetryDelay: time.Millisecond,
+		queue:          queue,
+		wake:           make(chan struct{}, 1),
+	}
+	go f.replay()
+
+	f.Publish(testPublication("mac0"))
+	f.Publish(testPublication("bad0"))
+	f.Publish(testPublication("mac1"))
+	<-dest.attempts
+	<-dest.attempts
+	dest.setAvailable(true)
+
+	if published := fmt.Sprint(dest.waitForPublished(t, 2)); published != "[mac0 mac1]" {
+		t.Errorf("Published %s, expected [mac0 mac1]", published)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		f.m.Lock()
+		queued := queue.len()
+		f.m.Unlock()
+		if queued == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the queue to be replayed, %d publications left", queued)
+		}
+	}
+
+	// Rejected publication is not queued when published directly.
+	f.Publish(testPublication("bad1"))
+	f.m.Lock()
+	queued := queue.len()
+	f.m.Unlock()
+	if queued != 0 {
+		t.Errorf("Rejected publication was queued, queue has %d publications", queued)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	MQTT "github.com/eclipse/paho.mqtt.golang"
+	api "github.com/p2004a/gbcsdpd/api"
+)
+
+// homeAssistantSensor is a Home Assistant entity of a measured quantity.
+type homeAssistantSensor struct {
+	component, key, name, deviceClass, unit, stateClass string
+	diagnostic                                          bool
+	// value returns the state of the entity and whatever the sensor measured
+	// it at all.
+	value func(m *api.Measurement) (interface{}, bool)
+}
+
+// floatState returns the value as state, values that are not measured are NaN.
+func floatState(v float32) (interface{}, bool) {
+	return v, !math.IsNaN(float64(v))
+}
+
+const microgramsPerCubicMeter = "µg/m³"
+
+var homeAssistantSensors = []homeAssistantSensor{
+	{"sensor", "temperature", "Temperature", "temperature", "°C", "measurement", false,
+		func(m *api.Measurement) (interface{}, bool) { return floatState(m.Temperature) }},
+	{"sensor", "humidity", "Humidity", "humidity", "%", "measurement", false,
+		func(m *api.Measurement) (interface{}, bool) { return floatState(m.Humidity) }},
+	{"sensor", "pressure", "Pressure", "atmospheric_pressure", "hPa", "measurement", false,
+		func(m *api.Measurement) (interface{}, bool) { return floatState(m.Pressure) }},
+	{"sensor", "illuminance", "Illuminance", "illuminance", "lx", "measurement", false,
+		func(m *api.Measurement) (interface{}, bool) { return floatState(m.Illuminance) }},
+	{"sensor", "co2", "CO2", "carbon_dioxide", "ppm", "measurement", false,
+		func(m *api.Measurement) (interface{}, bool) { return floatState(m.Co2) }},
+	{"sensor", "pm1", "PM1", "pm1", microgramsPerCubicMeter, "measurement", false,
+		func(m *api.Measurement) (interface{}, bool) { return floatState(m.Pm1) }},
+	{"sensor", "pm2_5", "PM2.5", "pm25", microgramsPerCubicMeter, "measurement", false,
+		func(m *api.Measurement) (interface{}, bool) { return floatState(m.Pm2_5) }},
+	// Home Assistant doesn't have device class for PM4.
+	{"sensor", "pm4", "PM4", "", microgramsPerCubicMeter, "measurement", false,
+		func(m *api.Measurement) (interface{}, bool) { return floatState(m.Pm4) }},
+	{"sensor", "pm10", "PM10", "pm10", microgramsPerCubicMeter, "measurement", false,
+		func(m *api.Measurement) (interface{}, bool) { return floatState(m.Pm10) }},
+	// Sensirion indexes are unitless, so they can't use the concentration
+	// device classes.
+	{"sensor", "voc_index", "VOC index", "", "", "measurement", false,
+		func(m *api.Measurement) (interface{}, bool) { return floatState(m.VocIndex) }},
+	{"sensor", "nox_index", "NOx index", "", "", "measurement", false,
+		func(m *api.Measurement) (interface{}, bool) { return floatState(m.NoxIndex) }},
+	{"binary_sensor", "motion", "Motion", "motion", "", "", false,
+		func(m *api.Measurement) (interface{}, bool) { return m.GetMotion(), m.Motion != nil }},
+	{"sensor", "movement_counter", "Movement counter", "", "", "total_increasing", false,
+		func(m *api.Measurement) (interface{}, bool) { return m.GetMovementCounter(), m.MovementCounter != nil }},
+	{"sensor", "battery_voltage", "Battery voltage", "voltage", "V", "measurement", true,
+		func(m *api.Measurement) (interface{}, bool) { return floatState(m.BatteryVoltage) }},
+	{"sensor", "battery_level", "Battery", "battery", "%", "measurement", true,
+		func(m *api.Measurement) (interface{}, bool) { return floatState(m.BatteryLevel) }},
+	{"sensor", "rssi", "Signal strength", "signal_strength", "dBm", "measurement", true,
+		func(m *api.Measurement) (interface{}, bool) { return floatState(measurementRSSI(m)) }},
+}
+
+// valueTemplate returns template extracting state of the entity from the JSON
+// state message.
+func (sensor *homeAssistantSensor) valueTemplate() string {
+	if sensor.component == "binary_sensor" {
+		return fmt.Sprintf("{{ 'ON' if value_json.%s else 'OFF' }}", sensor.key)
+	}
+	return fmt.Sprintf("{{ value_json.%s }}", sensor.key)
+}
+
+// homeAssistantDevice is the device part of the discovery message.
+type homeAssistantDevice struct {
+	Identifiers []string    `json:"identifiers"`
+	Connections [][2]string `json:"connections"`
+	Name        string      `json:"name"`
+}
+
+// homeAssistantDiscovery is the payload of the discovery message, see
+// https://www.home-assistant.io/integrations/sensor.mqtt/
+type homeAssistantDiscovery struct {
+	Name              string              `json:"name"`
+	UniqueID          string              `json:"unique_id"`
+	StateTopic        string              `json:"state_topic"`
+	ValueTemplate     string              `json:"value_template"`
+	DeviceClass       string              `json:"device_class,omitempty"`
+	UnitOfMeasurement string              `json:"unit_of_measurement,omitempty"`
+	StateClass        string              `json:"state_class,omitempty"`
+	EntityCategory    string              `json:"entity_category,omitempty"`
+	ExpireAfter       int                 `json:"expire_after,omitempty"`
+	Device            homeAssistantDevice `json:"device"`
+}
+
+// homeAssistantNodeID returns identifier of the sensor used in topics and
+// unique IDs, eg gbcsdpd_a4c138000001.
+func homeAssistantNodeID(mac string) string {
+	return "gbcsdpd_" + strings.ToLower(strings.ReplaceAll(mac, ":", ""))
+}
+
+func (s *MQTTSink) homeAssistantStateTopic(mac string) string {
+	return strings.TrimSuffix(s.topic, "/") + "/" + strings.ToLower(strings.ReplaceAll(mac, ":", ""))
+}
+
+// announce publishes retained discovery messages of the measured quantities
+// of the sensor that weren't announced yet.
+func (s *MQTTSink) announce(m *api.Measurement) error {
+	nodeID := homeAssistantNodeID(m.SensorMac)
+	for _, sensor := range homeAssistantSensors {
+		if _, ok := sensor.value(m); !ok {
+			continue
+		}
+		topic := fmt.Sprintf("%s/%s/%s/%s/config", s.homeAssistant.DiscoveryPrefix, sensor.component, nodeID, sensor.key)
+		s.m.Lock()
+		announced := s.announced[topic]
+		s.m.Unlock()
+		if announced {
+			continue
+		}
+		discovery := homeAssistantDiscovery{
+			Name:              sensor.name,
+			UniqueID:          nodeID + "_" + sensor.key,
+			StateTopic:        s.homeAssistantStateTopic(m.SensorMac),
+			ValueTemplate:     sensor.valueTemplate(),
+			DeviceClass:       sensor.deviceClass,
+			UnitOfMeasurement: sensor.unit,
+			StateClass:        sensor.stateClass,
+			ExpireAfter:       int(s.homeAssistant.ExpireAfter.Seconds()),
+			Device: homeAssistantDevice{
+				Identifiers: []string{nodeID},
+				Connections: [][2]string{{"mac", strings.ToLower(m.SensorMac)}},
+				Name:        m.SensorMac,
+			},
+		}
+		if sensor.diagnostic {
+			discovery.EntityCategory = "diagnostic"
+		}
+		payload, err := json.Marshal(discovery)
+		if err != nil {
+			return fmt.Errorf("failed to json encode discovery message: %v", err)
+		}
+		if err := s.publishMessage(topic, 1, true, payload); err != nil {
+			return fmt.Errorf("failed to publish discovery message: %v", err)
+		}
+		s.m.Lock()
+		s.announced[topic] = true
+		s.m.Unlock()
+	}
+	return nil
+}
+
+// publishHomeAssistant announces sensors and publishes their measurements to
+// the state topics.
+func (s *MQTTSink) publishHomeAssistant(ms []*api.Measurement) error {
+	for _, m := range ms {
+		if err := s.announce(m); err != nil {
+			return err
+		}
+		state := make(map[string]interface{})
+		for _, sensor := range homeAssistantSensors {
+			if v, ok := sensor.value(m); ok {
+				state[sensor.key] = v
+			}
+		}
+		if len(state) == 0 {
+			continue
+		}
+		payload, err := json.Marshal(state)
+		if err != nil {
+			return fmt.Errorf("failed to json encode state: %v", err)
+		}
+		if err := s.publishMessage(s.homeAssistantStateTopic(m.SensorMac), s.qos, false, payload); err != nil {
+			return fmt.Errorf("failed to publish state: %v", err)
+		}
+	}
+	return nil
+}
+
+// subscribeHomeAssistantStatus subscribes to the Home Assistant status topic,
+// so sensors are announced again when Home Assistant restarts.
+func (s *MQTTSink) subscribeHomeAssistantStatus(client MQTT.Client) {
+	topic := s.homeAssistant.DiscoveryPrefix + "/status"
+	token := client.Subscribe(topic, 1, func(_ MQTT.Client, msg MQTT.Message) {
+		if string(msg.Payload()) != "online" {
+			return
+		}
+		s.m.Lock()
+		s.announced = make(map[string]bool)
+		s.m.Unlock()
+	})
+	if token.Wait() && token.Error() != nil {
+		log.Printf("Failed to subscribe to %s: %v", topic, token.Error())
+	}
+}
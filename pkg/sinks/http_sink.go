@@ -0,0 +1,162 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/backoff"
+	"github.com/p2004a/gbcsdpd/pkg/config"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// HTTPSink POSTs measurements publications to HTTP endpoint.
+type HTTPSink struct {
+	config         *config.HTTPSink
+	client         *http.Client
+	baseRetryDelay time.Duration
+	rl             *rateLimiter
+	fw             *forwarder
+	pending        chan *api.MeasurementsPublication
+}
+
+// httpSinkPendingSize is the number of publications waiting for delivery
+// after which new ones are dropped.
+const httpSinkPendingSize = 64
+
+// Publish is used to push measurement for publication.
+func (s *HTTPSink) Publish(m *api.Measurement) {
+	s.rl.Publish(m)
+}
+
+// groupPublish hands publication over to deliver, so posting and retries
+// never block the caller.
+func (s *HTTPSink) groupPublish(ms []*api.Measurement) {
+	select {
+	case s.pending <- &api.MeasurementsPublication{Measurements: ms}:
+	default:
+		log.Printf("HTTP sink %s can't keep up, dropping publication", s.config.Name)
+	}
+}
+
+func (s *HTTPSink) deliver() {
+	for pub := range s.pending {
+		s.fw.Publish(pub)
+	}
+}
+
+// httpStatusError is returned when the endpoint responds with non-2xx status.
+type httpStatusError struct {
+	status string
+	code   int
+	msg    []byte
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("server returned %s: %s", e.status, e.msg)
+}
+
+// retryable returns whatever the request might succeed when retried.
+func (e *httpStatusError) retryable() bool {
+	return e.code >= 500 || e.code == http.StatusRequestTimeout || e.code == http.StatusTooManyRequests
+}
+
+func (s *HTTPSink) post(body []byte, contentType string) error {
+	req, err := http.NewRequest(http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	for name, value := range s.config.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.BearerToken)
+	} else if s.config.BasicAuth != nil {
+		req.SetBasicAuth(s.config.BasicAuth.UserName, s.config.BasicAuth.Password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &httpStatusError{resp.Status, resp.StatusCode, bytes.TrimSpace(msg)}
+	}
+	// Drain the body, so the connection can be reused.
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (s *HTTPSink) publish(pub *api.MeasurementsPublication) error {
+	var body []byte
+	var contentType string
+	if s.config.Format == config.BINARY {
+		serPub, err := proto.Marshal(pub)
+		if err != nil {
+			log.Fatalf("Failed to binary encode measurement: %v", err)
+		}
+		body, contentType = serPub, "application/x-protobuf"
+	} else if s.config.Format == config.JSON {
+		jsonPub, err := protojson.Marshal(pub)
+		if err != nil {
+			log.Fatalf("Failed to json encode measurement: %v", err)
+		}
+		body, contentType = jsonPub, "application/json"
+	} else {
+		log.Fatalf("Unknown data publication format: %v", s.config.Format)
+	}
+
+	var err error
+	for retryNum := 0; retryNum <= s.config.MaxRetries; retryNum++ {
+		time.Sleep(backoff.Exponential(retryNum, s.baseRetryDelay, 30*time.Second, 2.0))
+		err = s.post(body, contentType)
+		if err == nil {
+			return nil
+		}
+		if statusErr, ok := err.(*httpStatusError); ok && !statusErr.retryable() {
+			return &permanentError{fmt.Errorf("failed to post to %s: %v", s.config.URL, err)}
+		}
+	}
+	return fmt.Errorf("failed to post to %s: %v", s.config.URL, err)
+}
+
+// NewHTTPSink creates new HTTPSink.
+func NewHTTPSink(config *config.HTTPSink) (*HTTPSink, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config.TLSConfig
+	s := &HTTPSink{
+		config:         config,
+		client:         &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		baseRetryDelay: time.Second,
+		pending:        make(chan *api.MeasurementsPublication, httpSinkPendingSize),
+	}
+	var err error
+	s.fw, err = newForwarder(config.Queue, s.publish)
+	if err != nil {
+		return nil, err
+	}
+	go s.deliver()
+	s.rl = newRateLimiter(config.RateLimit, s.groupPublish)
+	return s, nil
+}
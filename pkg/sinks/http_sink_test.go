@@ -0,0 +1,180 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/config"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+type httpRequest struct {
+	Authorization, ContentType, Gateway string
+	Body                                []byte
+}
+
+// fakeEndpoint responds with the given status codes in order, and with 204
+// afterwards.
+type fakeEndpoint struct {
+	m        sync.Mutex
+	statuses []int
+	requests []httpRequest
+}
+
+func (e *fakeEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.requests = append(e.requests, httpRequest{
+		Authorization: r.Header.Get("Authorization"),
+		ContentType:   r.Header.Get("Content-Type"),
+		Gateway:       r.Header.Get("X-Gateway"),
+		Body:          body,
+	})
+	status := http.StatusNoContent
+	if len(e.statuses) > 0 {
+		status, e.statuses = e.statuses[0], e.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func newTestHTTPSink(t *testing.T, server *httptest.Server, c *config.HTTPSink) *HTTPSink {
+	certPool := x509.NewCertPool()
+	certPool.AddCert(server.Certificate())
+	c.URL = server.URL + "/hook"
+	c.TLSConfig = &tls.Config{RootCAs: certPool}
+	sink, err := NewHTTPSink(c)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	sink.baseRetryDelay = time.Millisecond
+	return sink
+}
+
+func TestHTTPSinkRetries(t *testing.T) {
+	endpoint := &fakeEndpoint{statuses: []int{http.StatusServiceUnavailable, http.StatusBadGateway}}
+	server := httptest.NewTLSServer(endpoint)
+	defer server.Close()
+	sink := newTestHTTPSink(t, server, &config.HTTPSink{
+		Name:        "http",
+		Format:      config.JSON,
+		Headers:     map[string]string{"X-Gateway": "attic"},
+		BearerToken: "secret",
+		MaxRetries:  3,
+	})
+
+	pub := testPublication("A4:C1:38:00:00:01")
+	if err := sink.publish(pub); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if len(endpoint.requests) != 3 {
+		t.Fatalf("Endpoint got %d requests, expected 3", len(endpoint.requests))
+	}
+	req := endpoint.requests[2]
+	if diff := cmp.Diff(req, httpRequest{
+		Authorization: "Bearer secret",
+		ContentType:   "application/json",
+		Gateway:       "attic",
+		Body:          req.Body,
+	}); diff != "" {
+		t.Errorf("Unexpected request:\n%v", diff)
+	}
+	received := &api.MeasurementsPublication{}
+	if err := protojson.Unmarshal(req.Body, received); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if diff := cmp.Diff(received, pub, protocmp.Transform()); diff != "" {
+		t.Errorf("Unexpected publication:\n%v", diff)
+	}
+}
+
+func TestHTTPSinkFailures(t *testing.T) {
+	endpoint := &fakeEndpoint{statuses: []int{
+		http.StatusBadRequest,
+		http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError,
+	}}
+	server := httptest.NewTLSServer(endpoint)
+	defer server.Close()
+	sink := newTestHTTPSink(t, server, &config.HTTPSink{
+		Name:       "http",
+		Format:     config.BINARY,
+		BasicAuth:  &config.BasicAuth{UserName: "user", Password: "pass"},
+		MaxRetries: 2,
+	})
+
+	// Client errors are not retried.
+	if err := sink.publish(testPublication("A4:C1:38:00:00:01")); err == nil || !isPermanent(err) {
+		t.Errorf("Expected permanent error for 400 response, got %v", err)
+	}
+	if len(endpoint.requests) != 1 {
+		t.Errorf("Endpoint got %d requests, expected 1", len(endpoint.requests))
+	}
+	// Server errors are retried up to the limit.
+	if err := sink.publish(testPublication("A4:C1:38:00:00:01")); err == nil || isPermanent(err) {
+		t.Errorf("Expected retryable error for 500 responses, got %v", err)
+	}
+	if len(endpoint.requests) != 4 {
+		t.Errorf("Endpoint got %d requests, expected 4", len(endpoint.requests))
+	}
+	if req := endpoint.requests[0]; req.Authorization != "Basic dXNlcjpwYXNz" || req.ContentType != "application/x-protobuf" {
+		t.Errorf("Unexpected request headers: %+v", req)
+	}
+}
+
+func TestHTTPSinkPublishDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	requests := make(chan struct{}, 10)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	defer close(release)
+	sink := newTestHTTPSink(t, server, &config.HTTPSink{
+		Name:       "http",
+		Format:     config.JSON,
+		MaxRetries: 3,
+	})
+
+	published := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			sink.Publish(&api.Measurement{SensorMac: "A4:C1:38:00:00:01"})
+		}
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatalf("Publish blocked while endpoint is failing")
+	}
+	select {
+	case <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for request")
+	}
+}
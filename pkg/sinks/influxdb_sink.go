@@ -0,0 +1,180 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/config"
+)
+
+// InfluxDBSink writes measurements to InfluxDB v2 in the line protocol.
+type InfluxDBSink struct {
+	config   *config.InfluxDBSink
+	writeURL string
+	client   *http.Client
+	rl       *rateLimiter
+	fw       *forwarder
+}
+
+// Publish is used to push measurement for publication.
+func (s *InfluxDBSink) Publish(m *api.Measurement) {
+	s.rl.Publish(m)
+}
+
+func (s *InfluxDBSink) groupPublish(ms []*api.Measurement) {
+	s.fw.Publish(&api.MeasurementsPublication{Measurements: ms})
+}
+
+var lineProtocolTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+var lineProtocolMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+
+func formatLineProtocolFloat(v float32) string {
+	return strconv.FormatFloat(float64(v), 'g', -1, 32)
+}
+
+// appendLineProtocol appends measurement as a line protocol point, see
+// https://docs.influxdata.com/influxdb/v2/reference/syntax/line-protocol/
+// Values not measured by the sensor are skipped, and so is the whole point
+// when there are no values at all.
+func appendLineProtocol(b *strings.Builder, measurement string, m *api.Measurement) {
+	var fields []string
+	for _, f := range []struct {
+		name  string
+		value float32
+	}{
+		{"temperature", m.Temperature},
+		{"humidity", m.Humidity},
+		{"pressure", m.Pressure},
+		{"illuminance", m.Illuminance},
+		{"battery_voltage", m.BatteryVoltage},
+		{"battery_level", m.BatteryLevel},
+		{"tx_power", m.TxPower},
+		{"co2", m.Co2},
+		{"pm1", m.Pm1},
+		{"pm2_5", m.Pm2_5},
+		{"pm4", m.Pm4},
+		{"pm10", m.Pm10},
+		{"voc_index", m.VocIndex},
+		{"nox_index", m.NoxIndex},
+		{"acceleration_x", m.AccelerationX},
+		{"acceleration_y", m.AccelerationY},
+		{"acceleration_z", m.AccelerationZ},
+		{"rssi", measurementRSSI(m)},
+	} {
+		if !math.IsNaN(float64(f.value)) {
+			fields = append(fields, f.name+"="+formatLineProtocolFloat(f.value))
+		}
+	}
+	if m.MeasurementSequenceNumber != nil {
+		fields = append(fields, fmt.Sprintf("measurement_sequence_number=%di", *m.MeasurementSequenceNumber))
+	}
+	if m.MovementCounter != nil {
+		fields = append(fields, fmt.Sprintf("movement_counter=%di", *m.MovementCounter))
+	}
+	if m.Motion != nil {
+		fields = append(fields, fmt.Sprintf("motion=%t", *m.Motion))
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	b.WriteString(lineProtocolMeasurementEscaper.Replace(measurement))
+	b.WriteString(",sensor_mac=")
+	b.WriteString(lineProtocolTagEscaper.Replace(m.SensorMac))
+	if m.Adapter != "" {
+		b.WriteString(",adapter=")
+		b.WriteString(lineProtocolTagEscaper.Replace(m.Adapter))
+	}
+	b.WriteByte(' ')
+	b.WriteString(strings.Join(fields, ","))
+
+	if m.Timestamp != nil {
+		fmt.Fprintf(b, " %d", m.Timestamp.AsTime().UnixNano())
+	}
+	b.WriteByte('\n')
+}
+
+func (s *InfluxDBSink) publish(pub *api.MeasurementsPublication) error {
+	var lines strings.Builder
+	for _, m := range pub.Measurements {
+		appendLineProtocol(&lines, s.config.Measurement, m)
+	}
+	if lines.Len() == 0 {
+		return nil
+	}
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	if _, err := io.WriteString(gz, lines.String()); err != nil {
+		return fmt.Errorf("failed to compress points: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress points: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.writeURL, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create write request: %v", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("Content-Encoding", "gzip")
+	if s.config.Token != "" {
+		req.Header.Set("Authorization", "Token "+s.config.Token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write points: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		statusErr := &httpStatusError{resp.Status, resp.StatusCode, bytes.TrimSpace(msg)}
+		if !statusErr.retryable() {
+			return &permanentError{fmt.Errorf("failed to write points, %v", statusErr)}
+		}
+		return fmt.Errorf("failed to write points, %v", statusErr)
+	}
+	return nil
+}
+
+// NewInfluxDBSink creates new InfluxDBSink.
+func NewInfluxDBSink(config *config.InfluxDBSink) (*InfluxDBSink, error) {
+	query := url.Values{}
+	query.Set("org", config.Org)
+	query.Set("bucket", config.Bucket)
+	query.Set("precision", "ns")
+	s := &InfluxDBSink{
+		config:   config,
+		writeURL: config.URL + "/api/v2/write?" + query.Encode(),
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+	var err error
+	s.fw, err = newForwarder(config.Queue, s.publish)
+	if err != nil {
+		return nil, err
+	}
+	s.rl = newRateLimiter(config.RateLimit, s.groupPublish)
+	return s, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"compress/gzip"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/config"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// nanMeasurement returns measurement with all values not measured, as created
+// by decoders.
+func nanMeasurement(mac string) *api.Measurement {
+	nan := float32(math.NaN())
+	return &api.Measurement{
+		SensorMac:      mac,
+		Temperature:    nan,
+		Humidity:       nan,
+		Pressure:       nan,
+		Illuminance:    nan,
+		BatteryVoltage: nan,
+		BatteryLevel:   nan,
+		TxPower:        nan,
+		Co2:            nan,
+		Pm1:            nan,
+		Pm2_5:          nan,
+		Pm4:            nan,
+		Pm10:           nan,
+		VocIndex:       nan,
+		NoxIndex:       nan,
+		AccelerationX:  nan,
+		AccelerationY:  nan,
+		AccelerationZ:  nan,
+	}
+}
+
+type influxDBWrite struct {
+	Path, Query, Authorization, ContentEncoding, Body string
+}
+
+func TestInfluxDBSink(t *testing.T) {
+	writes := make(chan influxDBWrite, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("Failed to create gzip reader: %v", err)
+			http.Error(w, "bad body", http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(gz)
+		if err != nil {
+			t.Errorf("Failed to read body: %v", err)
+		}
+		writes <- influxDBWrite{
+			Path:            r.URL.Path,
+			Query:           r.URL.RawQuery,
+			Authorization:   r.Header.Get("Authorization"),
+			ContentEncoding: r.Header.Get("Content-Encoding"),
+			Body:            string(body),
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink, err := NewInfluxDBSink(&config.InfluxDBSink{
+		Name:        "influxdb",
+		URL:         server.URL,
+		Org:         "my org",
+		Bucket:      "telemetry",
+		Token:       "secret",
+		Measurement: "climate",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	seq := uint32(7)
+	motion := true
+	m := nanMeasurement("A4:C1:38:00:00:01")
+	m.Timestamp = timestamppb.New(time.Unix(1700000000, 5))
+	m.Temperature = 21.5
+	m.Humidity = 0
+	m.BatteryVoltage = 2.95
+	m.MeasurementSequenceNumber = &seq
+	m.Motion = &motion
+	m.Rssi = proto.Float32(-70)
+	m.Adapter = "hci 0"
+	sink.Publish(m)
+
+	select {
+	case write := <-writes:
+		if diff := cmp.Diff(write, influxDBWrite{
+			Path:            "/api/v2/write",
+			Query:           "bucket=telemetry&org=my+org&precision=ns",
+			Authorization:   "Token secret",
+			ContentEncoding: "gzip",
+			Body:            "climate,sensor_mac=A4:C1:38:00:00:01,adapter=hci\\ 0 temperature=21.5,humidity=0,battery_voltage=2.95,rssi=-70,measurement_sequence_number=7i,motion=true 1700000000000000005\n",
+		}); diff != "" {
+			t.Errorf("Unexpected write:\n%v", diff)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for write")
+	}
+}
+
+func TestAppendLineProtocolSkipsNaN(t *testing.T) {
+	var b strings.Builder
+	m := nanMeasurement("A4:C1:38:00:00:01")
+	appendLineProtocol(&b, "climate", m)
+	if b.Len() != 0 {
+		t.Errorf("Got line %q for measurement without values, expected nothing", b.String())
+	}
+	m.Humidity = 55
+	m.Pressure = 0
+	appendLineProtocol(&b, "climate", m)
+	if expected := "climate,sensor_mac=A4:C1:38:00:00:01 humidity=55,pressure=0\n"; b.String() != expected {
+		t.Errorf("Got line %q, expected %q", b.String(), expected)
+	}
+}
+
+func TestInfluxDBSinkError(t *testing.T) {
+	cases := []struct {
+		status    int
+		expected  string
+		permanent bool
+	}{
+		{http.StatusUnauthorized, `failed to write points, server returned 401 Unauthorized: {"code":"unauthorized","message":"unauthorized access"}`, true},
+		{http.StatusServiceUnavailable, `failed to write points, server returned 503 Service Unavailable: {"code":"unauthorized","message":"unauthorized access"}`, false},
+	}
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"code":"unauthorized","message":"unauthorized access"}`, c.status)
+		}))
+		defer server.Close()
+
+		sink, err := NewInfluxDBSink(&config.InfluxDBSink{
+			Name:        "influxdb",
+			URL:         server.URL,
+			Org:         "org",
+			Bucket:      "bucket",
+			Measurement: "gbcsdpd",
+		})
+		if err != nil {
+			t.Fatalf("Failed to create sink: %v", err)
+		}
+		err = sink.publish(testPublication("A4:C1:38:00:00:01"))
+		if err == nil {
+			t.Fatalf("Expected error when server rejects the write")
+		}
+		if err.Error() != c.expected {
+			t.Errorf("Got error %q, expected %q", err, c.expected)
+		}
+		if isPermanent(err) != c.permanent {
+			t.Errorf("Error for status %d is permanent: %t, expected %t", c.status, isPermanent(err), c.permanent)
+		}
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	MQTT "github.com/eclipse/paho.mqtt.golang"
@@ -39,7 +40,9 @@ func connectMQTTClientWithBackoff(client MQTT.Client) {
 	}
 }
 
-func createMQTTClient(clientID, server string, port int, tls *tls.Config, creds MQTT.CredentialsProvider) (MQTT.Client, error) {
+// The onConnect handler, if not nil, is called after every successful
+// connection.
+func createMQTTClient(clientID, server string, port int, tls *tls.Config, creds MQTT.CredentialsProvider, onConnect MQTT.OnConnectHandler) (MQTT.Client, error) {
 	opts := MQTT.NewClientOptions()
 	opts.SetClientID(clientID)
 	opts.SetKeepAlive(time.Minute)
@@ -58,6 +61,9 @@ func createMQTTClient(clientID, server string, port int, tls *tls.Config, creds
 		log.Printf("Disconnected %s (%v), reconnecting...", brokerAddr, err)
 		connectMQTTClientWithBackoff(client)
 	})
+	if onConnect != nil {
+		opts.SetOnConnectHandler(onConnect)
+	}
 
 	client := MQTT.NewClient(opts)
 	connectMQTTClientWithBackoff(client)
@@ -68,8 +74,14 @@ func createMQTTClient(clientID, server string, port int, tls *tls.Config, creds
 type MQTTSink struct {
 	mqttClient MQTT.Client
 	rl         *rateLimiter
+	fw         *forwarder
 	topic      string
+	qos        byte
 	format     config.PublicationFormat
+
+	homeAssistant *config.HomeAssistant
+	m             sync.Mutex      // Guards announced
+	announced     map[string]bool // Home Assistant discovery topics already published
 }
 
 // Publish is used to push measurement for publication.
@@ -78,22 +90,46 @@ func (s *MQTTSink) Publish(m *api.Measurement) {
 }
 
 func (s *MQTTSink) groupPublish(ms []*api.Measurement) {
-	pub := &api.MeasurementsPublication{Measurements: ms}
+	s.fw.Publish(&api.MeasurementsPublication{Measurements: ms})
+}
+
+func (s *MQTTSink) publish(pub *api.MeasurementsPublication) error {
+	var payload []byte
 	if s.format == config.BINARY {
 		serPub, err := proto.Marshal(pub)
 		if err != nil {
 			log.Fatalf("Failed to binary encode measurement: %v", err)
 		}
-		s.mqttClient.Publish(s.topic, 0, false, serPub)
+		payload = serPub
 	} else if s.format == config.JSON {
 		jsonPub, err := protojson.Marshal(pub)
 		if err != nil {
 			log.Fatalf("Failed to json encode measurement: %v", err)
 		}
-		s.mqttClient.Publish(s.topic, 0, false, jsonPub)
+		payload = jsonPub
 	} else {
 		log.Fatalf("Unknown data publication format: %v", s.format)
 	}
+	if err := s.publishMessage(s.topic, s.qos, false, payload); err != nil {
+		return err
+	}
+	if s.homeAssistant != nil {
+		if err := s.publishHomeAssistant(pub.Measurements); err != nil {
+			// The publication was already delivered, retrying would repeat it.
+			return &permanentError{err}
+		}
+	}
+	return nil
+}
+
+func (s *MQTTSink) publishMessage(topic string, qos byte, retained bool, payload []byte) error {
+	token := s.mqttClient.Publish(topic, qos, retained, payload)
+	if !token.WaitTimeout(30 * time.Second) {
+		// The client keeps delivering the message in the background, so
+		// publishing it again would create a duplicate.
+		return &permanentError{fmt.Errorf("timed out waiting for publication to complete")}
+	}
+	return token.Error()
 }
 
 // NewMQTTSink creates new MQTTSink.
@@ -101,14 +137,28 @@ func NewMQTTSink(c *config.MQTTSink) (*MQTTSink, error) {
 	creds := func() (string, string) {
 		return c.UserName, c.Password
 	}
-	mqttClient, err := createMQTTClient(c.ClientID, c.ServerName, c.ServerPort, c.TLSConfig, creds)
+	s := &MQTTSink{
+		topic:         c.Topic,
+		format:        c.Format,
+		homeAssistant: c.HomeAssistant,
+		announced:     make(map[string]bool),
+	}
+	var onConnect MQTT.OnConnectHandler
+	if s.homeAssistant != nil {
+		onConnect = s.subscribeHomeAssistantStatus
+	}
+	var err error
+	s.mqttClient, err = createMQTTClient(c.ClientID, c.ServerName, c.ServerPort, c.TLSConfig, creds, onConnect)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create MQTT client: %v", err)
 	}
-	s := &MQTTSink{
-		mqttClient: mqttClient,
-		topic:      c.Topic,
-		format:     c.Format,
+	if c.Queue != nil {
+		// With QoS 0, publications are silently dropped while reconnecting.
+		s.qos = 1
+	}
+	s.fw, err = newForwarder(c.Queue, s.publish)
+	if err != nil {
+		return nil, err
 	}
 	s.rl = newRateLimiter(c.RateLimit, s.groupPublish)
 	return s, nil
@@ -15,9 +15,11 @@
 package sinks
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -26,7 +28,9 @@ import (
 	"github.com/fhmq/hmq/broker"
 	"github.com/google/go-cmp/cmp"
 	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/blelistener"
 	"github.com/p2004a/gbcsdpd/pkg/config"
+	"github.com/p2004a/gbcsdpd/pkg/decoders"
 )
 
 const testerClientID = "testerclient"
@@ -95,7 +99,7 @@ func TestBorkerIntegration(t *testing.T) {
 	testUserName := "bob"
 	testPassword := "ilovealice"
 	sensorMac := "01:23:45:67:89:AB"
-	measuementsTopic := "/measurements"
+	measurementsTopic := "/measurements"
 
 	// Create and start MQTT broker.
 	port := pickFreePort()
@@ -120,7 +124,7 @@ func TestBorkerIntegration(t *testing.T) {
 
 	sink, err := NewMQTTSink(&config.MQTTSink{
 		Name:       "sink",
-		Topic:      measuementsTopic,
+		Topic:      measurementsTopic,
 		ClientID:   testClientID,
 		UserName:   testUserName,
 		Password:   testPassword,
@@ -153,8 +157,8 @@ func TestBorkerIntegration(t *testing.T) {
 		if count == 10 {
 			break
 		}
-		if msg.Topic != measuementsTopic {
-			t.Errorf("Received message on wrong topic. got: %s expected: %s", msg.Topic, measuementsTopic)
+		if msg.Topic != measurementsTopic {
+			t.Errorf("Received message on wrong topic. got: %s expected: %s", msg.Topic, measurementsTopic)
 		}
 
 		type Measurement struct {
@@ -182,3 +186,157 @@ func TestBorkerIntegration(t *testing.T) {
 		}
 	}
 }
+
+func TestHomeAssistantDiscovery(t *testing.T) {
+	port := pickFreePort()
+	b, err := broker.NewBroker(&broker.Config{
+		Worker: 1,
+		Host:   "127.0.0.1",
+		Port:   fmt.Sprintf("%d", port),
+		Plugin: broker.Plugins{
+			Auth: &singleUserAuth{ClientID: "pusher"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create broker: %v", err)
+	}
+	b.Start()
+	messages := subscribeToAllTopics(t, fmt.Sprintf("tcp://127.0.0.1:%d", port))
+
+	sink, err := NewMQTTSink(&config.MQTTSink{
+		Name:       "sink",
+		Topic:      "gbcsdpd/",
+		ClientID:   "pusher",
+		Format:     config.JSON,
+		ServerName: "127.0.0.1",
+		ServerPort: port,
+		HomeAssistant: &config.HomeAssistant{
+			DiscoveryPrefix: "homeassistant",
+			ExpireAfter:     10 * time.Minute,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mqtt sink: %v", err)
+	}
+	// The broker keeps retained messages globally, remove them so they are
+	// not received by other tests.
+	var discoveryTopics []string
+	defer func() {
+		for _, topic := range discoveryTopics {
+			if err := sink.publishMessage(topic, 1, true, nil); err != nil {
+				t.Errorf("Failed to remove retained discovery message: %v", err)
+			}
+		}
+	}()
+	// Decoder sets values not measured by the sensor to NaN, they must not be
+	// announced.
+	rssi := int16(-70)
+	m, err := decoders.NewBTHomeDecoder(&config.BTHomeDecoder{}).Decode(&blelistener.Advertisement{
+		Address:     net.HardwareAddr{0xa4, 0xc1, 0x38, 0x00, 0x00, 0x01},
+		RSSI:        &rssi,
+		ServiceData: blelistener.ServiceData{blelistener.ServiceDataUUID(0xfcd2): {0x40, 0x02, 0xca, 0x09, 0x03, 0xbf, 0x13}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to decode advertisement: %v", err)
+	}
+	sink.Publish(m)
+
+	expected := map[string]string{
+		"homeassistant/sensor/gbcsdpd_a4c138000001/temperature/config": `{"name":"Temperature","unique_id":"gbcsdpd_a4c138000001_temperature",` +
+			`"state_topic":"gbcsdpd/a4c138000001","value_template":"{{ value_json.temperature }}","device_class":"temperature",` +
+			`"unit_of_measurement":"°C","state_class":"measurement","expire_after":600,` +
+			`"device":{"identifiers":["gbcsdpd_a4c138000001"],"connections":[["mac","a4:c1:38:00:00:01"]],"name":"a4:c1:38:00:00:01"}}`,
+		"homeassistant/sensor/gbcsdpd_a4c138000001/humidity/config": `{"name":"Humidity","unique_id":"gbcsdpd_a4c138000001_humidity",` +
+			`"state_topic":"gbcsdpd/a4c138000001","value_template":"{{ value_json.humidity }}","device_class":"humidity",` +
+			`"unit_of_measurement":"%","state_class":"measurement","expire_after":600,` +
+			`"device":{"identifiers":["gbcsdpd_a4c138000001"],"connections":[["mac","a4:c1:38:00:00:01"]],"name":"a4:c1:38:00:00:01"}}`,
+		"homeassistant/sensor/gbcsdpd_a4c138000001/rssi/config": `{"name":"Signal strength","unique_id":"gbcsdpd_a4c138000001_rssi",` +
+			`"state_topic":"gbcsdpd/a4c138000001","value_template":"{{ value_json.rssi }}","device_class":"signal_strength",` +
+			`"unit_of_measurement":"dBm","state_class":"measurement","entity_category":"diagnostic","expire_after":600,` +
+			`"device":{"identifiers":["gbcsdpd_a4c138000001"],"connections":[["mac","a4:c1:38:00:00:01"]],"name":"a4:c1:38:00:00:01"}}`,
+		"gbcsdpd/a4c138000001": `{"humidity":50.55,"rssi":-70,"temperature":25.06}`,
+	}
+	received := make(map[string]string)
+	timeout := time.After(5 * time.Second)
+	for len(received) < len(expected) {
+		select {
+		case msg := <-messages:
+			// Discovery messages are published before the state, so
+			// unexpected ones are received before the loop ends.
+			if strings.HasPrefix(msg.Topic, "homeassistant/") {
+				discoveryTopics = append(discoveryTopics, msg.Topic)
+			}
+			if _, ok := expected[msg.Topic]; ok || strings.HasPrefix(msg.Topic, "homeassistant/") {
+				received[msg.Topic] = string(msg.Payload)
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for messages, received: %v", received)
+		}
+	}
+	if diff := cmp.Diff(received, expected); diff != "" {
+		t.Errorf("Unexpected messages:\n%v", diff)
+	}
+
+	// Sensor measuring air quality and motion.
+	data, err := hex.DecodeString("440009016402CA0903BF1304138A0105138A140C0D0C1202042101")
+	if err != nil {
+		t.Fatalf("Failed to decode hex: %v", err)
+	}
+	m, err = decoders.NewBTHomeDecoder(&config.BTHomeDecoder{}).Decode(&blelistener.Advertisement{
+		Address:     net.HardwareAddr{0xa4, 0xc1, 0x38, 0x00, 0x00, 0x02},
+		ServiceData: blelistener.ServiceData{blelistener.ServiceDataUUID(0xfcd2): data},
+	})
+	if err != nil {
+		t.Fatalf("Failed to decode advertisement: %v", err)
+	}
+	sink.Publish(m)
+
+	received = make(map[string]string)
+	for received["gbcsdpd/a4c138000002"] == "" {
+		select {
+		case msg := <-messages:
+			if strings.HasPrefix(msg.Topic, "homeassistant/") {
+				discoveryTopics = append(discoveryTopics, msg.Topic)
+			}
+			if strings.Contains(msg.Topic, "a4c138000002") {
+				received[msg.Topic] = string(msg.Payload)
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for messages, received: %v", received)
+		}
+	}
+	var topics []string
+	for topic := range received {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	if diff := cmp.Diff(topics, []string{
+		"gbcsdpd/a4c138000002",
+		"homeassistant/binary_sensor/gbcsdpd_a4c138000002/motion/config",
+		"homeassistant/sensor/gbcsdpd_a4c138000002/battery_level/config",
+		"homeassistant/sensor/gbcsdpd_a4c138000002/battery_voltage/config",
+		"homeassistant/sensor/gbcsdpd_a4c138000002/co2/config",
+		"homeassistant/sensor/gbcsdpd_a4c138000002/humidity/config",
+		"homeassistant/sensor/gbcsdpd_a4c138000002/illuminance/config",
+		"homeassistant/sensor/gbcsdpd_a4c138000002/pressure/config",
+		"homeassistant/sensor/gbcsdpd_a4c138000002/temperature/config",
+	}); diff != "" {
+		t.Errorf("Unexpected topics:\n%v", diff)
+	}
+	for topic, expected := range map[string]string{
+		"homeassistant/binary_sensor/gbcsdpd_a4c138000002/motion/config": `{"name":"Motion","unique_id":"gbcsdpd_a4c138000002_motion",` +
+			`"state_topic":"gbcsdpd/a4c138000002","value_template":"{{ 'ON' if value_json.motion else 'OFF' }}","device_class":"motion",` +
+			`"expire_after":600,` +
+			`"device":{"identifiers":["gbcsdpd_a4c138000002"],"connections":[["mac","a4:c1:38:00:00:02"]],"name":"a4:c1:38:00:00:02"}}`,
+		"homeassistant/sensor/gbcsdpd_a4c138000002/co2/config": `{"name":"CO2","unique_id":"gbcsdpd_a4c138000002_co2",` +
+			`"state_topic":"gbcsdpd/a4c138000002","value_template":"{{ value_json.co2 }}","device_class":"carbon_dioxide",` +
+			`"unit_of_measurement":"ppm","state_class":"measurement","expire_after":600,` +
+			`"device":{"identifiers":["gbcsdpd_a4c138000002"],"connections":[["mac","a4:c1:38:00:00:02"]],"name":"a4:c1:38:00:00:02"}}`,
+		"gbcsdpd/a4c138000002": `{"battery_level":100,"battery_voltage":3.085,"co2":1026,"humidity":50.55,` +
+			`"illuminance":13460.67,"motion":true,"pressure":1008.83,"temperature":25.06}`,
+	} {
+		if diff := cmp.Diff(received[topic], expected); diff != "" {
+			t.Errorf("Unexpected message on %s:\n%v", topic, diff)
+		}
+	}
+}
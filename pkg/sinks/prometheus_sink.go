@@ -0,0 +1,137 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/config"
+)
+
+type prometheusGauge struct {
+	name, help string
+	value      func(m *api.Measurement) float32
+}
+
+var prometheusGauges = []prometheusGauge{
+	{"gbcsdpd_temperature_celsius", "Temperature measured by the sensor.",
+		func(m *api.Measurement) float32 { return m.Temperature }},
+	{"gbcsdpd_humidity_percent", "Relative humidity measured by the sensor.",
+		func(m *api.Measurement) float32 { return m.Humidity }},
+	{"gbcsdpd_pressure_hpa", "Atmospheric pressure measured by the sensor.",
+		func(m *api.Measurement) float32 { return m.Pressure }},
+	{"gbcsdpd_battery_volts", "Battery voltage of the sensor.",
+		func(m *api.Measurement) float32 { return m.BatteryVoltage }},
+	{"gbcsdpd_battery_percent", "Battery level of the sensor.",
+		func(m *api.Measurement) float32 { return m.BatteryLevel }},
+	{"gbcsdpd_rssi_dbm", "Signal strength of the last advertisement from the sensor.",
+		func(m *api.Measurement) float32 { return measurementRSSI(m) }},
+}
+
+type prometheusEntry struct {
+	m       *api.Measurement
+	updated time.Time
+}
+
+// PrometheusSink serves the latest measurement of every sensor as Prometheus
+// gauges over HTTP. Sensors that didn't send a measurement for the configured
+// duration are dropped.
+type PrometheusSink struct {
+	config   *config.PrometheusSink
+	listener net.Listener
+	now      func() time.Time
+	m        sync.Mutex // Guards latest
+	latest   map[string]prometheusEntry
+}
+
+// Publish is used to push measurement for publication.
+func (s *PrometheusSink) Publish(m *api.Measurement) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.latest[m.SensorMac] = prometheusEntry{m, s.now()}
+}
+
+// measurements returns the latest measurements of sensors that are not stale
+// sorted by MAC.
+func (s *PrometheusSink) measurements() []*api.Measurement {
+	s.m.Lock()
+	defer s.m.Unlock()
+	now := s.now()
+	var ms []*api.Measurement
+	for mac, entry := range s.latest {
+		if now.Sub(entry.updated) > s.config.StaleAfter {
+			delete(s.latest, mac)
+			continue
+		}
+		ms = append(ms, entry.m)
+	}
+	sort.Sort(byMac(ms))
+	return ms
+}
+
+// writeMetrics writes the measurements in the Prometheus text exposition
+// format, see https://prometheus.io/docs/instrumenting/exposition_formats/
+// Values not measured by the sensor are skipped.
+func writeMetrics(w io.Writer, ms []*api.Measurement) error {
+	var b strings.Builder
+	for _, g := range prometheusGauges {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+		for _, m := range ms {
+			if v := g.value(m); !math.IsNaN(float64(v)) {
+				fmt.Fprintf(&b, "%s{sensor_mac=%q} %s\n", g.name, m.SensorMac, strconv.FormatFloat(float64(v), 'g', -1, 32))
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (s *PrometheusSink) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := writeMetrics(w, s.measurements()); err != nil {
+		log.Printf("Failed to write metrics: %v", err)
+	}
+}
+
+// NewPrometheusSink creates new PrometheusSink and starts serving metrics.
+func NewPrometheusSink(config *config.PrometheusSink) (*PrometheusSink, error) {
+	listener, err := net.Listen("tcp", config.ListenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", config.ListenAddress, err)
+	}
+	s := &PrometheusSink{
+		config:   config,
+		listener: listener,
+		now:      time.Now,
+		latest:   make(map[string]prometheusEntry),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(config.Path, s.serveMetrics)
+	go func() {
+		log.Fatalf("Prometheus sink %s stopped serving: %v", config.Name, http.Serve(listener, mux))
+	}()
+	return s, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	api "github.com/p2004a/gbcsdpd/api"
+	"github.com/p2004a/gbcsdpd/pkg/config"
+	"google.golang.org/protobuf/proto"
+)
+
+func scrape(t *testing.T, url string) string {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("Failed to get metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected status code: %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+	return string(body)
+}
+
+func TestPrometheusSink(t *testing.T) {
+	sink, err := NewPrometheusSink(&config.PrometheusSink{
+		Name:          "prometheus",
+		ListenAddress: "127.0.0.1:0",
+		Path:          "/metrics",
+		StaleAfter:    5 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	var m sync.Mutex
+	now := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	sink.now = func() time.Time {
+		m.Lock()
+		defer m.Unlock()
+		return now
+	}
+	url := "http://" + sink.listener.Addr().String() + "/metrics"
+
+	nan := float32(math.NaN())
+	// Humidity of zero is a valid value, missing values are NaN.
+	sink.Publish(&api.Measurement{SensorMac: "A4:C1:38:00:00:02", Temperature: 18.5, Humidity: 0,
+		Pressure: nan, BatteryVoltage: nan, BatteryLevel: nan})
+	m.Lock()
+	now = now.Add(3 * time.Minute)
+	m.Unlock()
+	sink.Publish(&api.Measurement{SensorMac: "A4:C1:38:00:00:01", Temperature: -2.25, Humidity: 81.5,
+		Pressure: 1013.2, BatteryVoltage: 2.9, BatteryLevel: nan, Rssi: proto.Float32(-70)})
+
+	expected := `# HELP gbcsdpd_temperature_celsius Temperature measured by the sensor.
+# TYPE gbcsdpd_temperature_celsius gauge
+gbcsdpd_temperature_celsius{sensor_mac="A4:C1:38:00:00:01"} -2.25
+gbcsdpd_temperature_celsius{sensor_mac="A4:C1:38:00:00:02"} 18.5
+# HELP gbcsdpd_humidity_percent Relative humidity measured by the sensor.
+# TYPE gbcsdpd_humidity_percent gauge
+gbcsdpd_humidity_percent{sensor_mac="A4:C1:38:00:00:01"} 81.5
+gbcsdpd_humidity_percent{sensor_mac="A4:C1:38:00:00:02"} 0
+# HELP gbcsdpd_pressure_hpa Atmospheric pressure measured by the sensor.
+# TYPE gbcsdpd_pressure_hpa gauge
+gbcsdpd_pressure_hpa{sensor_mac="A4:C1:38:00:00:01"} 1013.2
+# HELP gbcsdpd_battery_volts Battery voltage of the sensor.
+# TYPE gbcsdpd_battery_volts gauge
+gbcsdpd_battery_volts{sensor_mac="A4:C1:38:00:00:01"} 2.9
+# HELP gbcsdpd_battery_percent Battery level of the sensor.
+# TYPE gbcsdpd_battery_percent gauge
+# HELP gbcsdpd_rssi_dbm Signal strength of the last advertisement from the sensor.
+# TYPE gbcsdpd_rssi_dbm gauge
+gbcsdpd_rssi_dbm{sensor_mac="A4:C1:38:00:00:01"} -70
+`
+	if diff := cmp.Diff(scrape(t, url), expected); diff != "" {
+		t.Errorf("Unexpected metrics:\n%v", diff)
+	}
+
+	// The first sensor is stale now.
+	m.Lock()
+	now = now.Add(3 * time.Minute)
+	m.Unlock()
+	expected = `# HELP gbcsdpd_temperature_celsius Temperature measured by the sensor.
+# TYPE gbcsdpd_temperature_celsius gauge
+gbcsdpd_temperature_celsius{sensor_mac="A4:C1:38:00:00:01"} -2.25
+# HELP gbcsdpd_humidity_percent Relative humidity measured by the sensor.
+# TYPE gbcsdpd_humidity_percent gauge
+gbcsdpd_humidity_percent{sensor_mac="A4:C1:38:00:00:01"} 81.5
+# HELP gbcsdpd_pressure_hpa Atmospheric pressure measured by the sensor.
+# TYPE gbcsdpd_pressure_hpa gauge
+gbcsdpd_pressure_hpa{sensor_mac="A4:C1:38:00:00:01"} 1013.2
+# HELP gbcsdpd_battery_volts Battery voltage of the sensor.
+# TYPE gbcsdpd_battery_volts gauge
+gbcsdpd_battery_volts{sensor_mac="A4:C1:38:00:00:01"} 2.9
+# HELP gbcsdpd_battery_percent Battery level of the sensor.
+# TYPE gbcsdpd_battery_percent gauge
+# HELP gbcsdpd_rssi_dbm Signal strength of the last advertisement from the sensor.
+# TYPE gbcsdpd_rssi_dbm gauge
+gbcsdpd_rssi_dbm{sensor_mac="A4:C1:38:00:00:01"} -70
+`
+	if diff := cmp.Diff(scrape(t, url), expected); diff != "" {
+		t.Errorf("Unexpected metrics after staleness timeout:\n%v", diff)
+	}
+}
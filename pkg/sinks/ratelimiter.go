@@ -50,7 +50,11 @@ func (rl *rateLimiter) limiter() {
 	for {
 		select {
 		case m := <-rl.measurements:
-			mset[m.SensorMac] = m
+			// Keep the most recent measurement, they might arrive out of order
+			// when the sensor is heard by multiple listeners.
+			if prev, ok := mset[m.SensorMac]; !ok || !m.Timestamp.AsTime().Before(prev.Timestamp.AsTime()) {
+				mset[m.SensorMac] = m
+			}
 		case <-deadline:
 			if len(mset) > 0 {
 				var ms []*api.Measurement
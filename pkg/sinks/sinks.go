@@ -16,6 +16,7 @@ package sinks
 
 import (
 	"fmt"
+	"math"
 
 	api "github.com/p2004a/gbcsdpd/api"
 	"github.com/p2004a/gbcsdpd/pkg/config"
@@ -26,6 +27,15 @@ type Sink interface {
 	Publish(*api.Measurement)
 }
 
+// measurementRSSI returns RSSI of the measurement, or NaN when it wasn't
+// reported, like for other values.
+func measurementRSSI(m *api.Measurement) float32 {
+	if m.Rssi == nil {
+		return float32(math.NaN())
+	}
+	return *m.Rssi
+}
+
 // NewSink creates a new Sink objects based on the config.Sink configuration.
 func NewSink(sinkConfig config.Sink) (Sink, error) {
 	switch s := sinkConfig.(type) {
@@ -35,6 +45,12 @@ func NewSink(sinkConfig config.Sink) (Sink, error) {
 		return NewStdoutSink(s)
 	case *config.MQTTSink:
 		return NewMQTTSink(s)
+	case *config.PrometheusSink:
+		return NewPrometheusSink(s)
+	case *config.InfluxDBSink:
+		return NewInfluxDBSink(s)
+	case *config.HTTPSink:
+		return NewHTTPSink(s)
 	default:
 		return nil, fmt.Errorf("unknown sink config type: %v", sinkConfig)
 	}